@@ -0,0 +1,274 @@
+/*
+dbx : administra los archivos de conexion encriptados .dbx
+
+Uso:
+
+	dbx create [-i] [-ini archivo.ini] [-tp POST -host localhost -port 5432 -user u -pass p -name db] -out conexion.dbx
+	dbx show conexion.dbx
+	dbx rekey [-newkey clave] conexion.dbx
+	dbx test conexion.dbx
+	dbx validate conexion.dbx
+
+La clave de encriptacion se envia con -key, con la variable de entorno DBXPASS o se pide por consola.
+*/
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	db "github.com/rafael180496/core-util/database"
+	utl "github.com/rafael180496/core-util/utility"
+)
+
+type (
+	/*command : subcomando de la herramienta*/
+	command struct {
+		usage string
+		run   func(args []string) error
+	}
+)
+
+var (
+	stdin    = bufio.NewReader(os.Stdin)
+	commands = map[string]command{
+		"create":   {"crea un archivo .dbx de forma interactiva, con parametros o de un .ini", create},
+		"show":     {"muestra la conexion desencriptada con la clave de la base de datos oculta", show},
+		"rekey":    {"cambia la clave de encriptacion de un archivo .dbx", rekey},
+		"test":     {"desencripta el archivo y prueba la conexion a la base de datos", test},
+		"validate": {"desencripta el archivo y valida los datos de la conexion", validate},
+	}
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+	err := cmd.run(os.Args[2:])
+	if err != nil {
+		utl.PrintRed("dbx %s: %s\n", os.Args[1], err.Error())
+		os.Exit(1)
+	}
+}
+
+/*usage : muestra la ayuda de los subcomandos*/
+func usage() {
+	fmt.Fprintln(os.Stderr, "uso: dbx <comando> [opciones]")
+	for _, name := range []string{"create", "show", "rekey", "test", "validate"} {
+		fmt.Fprintf(os.Stderr, "  %-9s %s\n", name, commands[name].usage)
+	}
+}
+
+/*create : crea un archivo .dbx*/
+func create(args []string) error {
+	var cad db.StCadConect
+	flags := flag.NewFlagSet("create", flag.ExitOnError)
+	interactive := flags.Bool("i", false, "pide los datos de la conexion por consola")
+	iniPath := flags.String("ini", "", "archivo .ini con la seccion [database]")
+	out := flags.String("out", "", "ruta del archivo .dbx a crear")
+	force := flags.Bool("force", false, "reemplaza el archivo si existe")
+	key := flags.String("key", "", "clave de encriptacion")
+	flags.StringVar(&cad.TP, "tp", "", "tipo de base de datos (POST,MYSQL,SQLSER,ORA,SQLLITE)")
+	flags.StringVar(&cad.Host, "host", "", "servidor")
+	flags.IntVar(&cad.Port, "port", 0, "puerto")
+	flags.StringVar(&cad.User, "user", "", "usuario")
+	flags.StringVar(&cad.Pass, "pass", "", "clave de la base de datos")
+	flags.StringVar(&cad.Name, "name", "", "nombre de la base de datos o service name")
+	flags.StringVar(&cad.Sslmode, "sslmode", "", "modo ssl")
+	flags.StringVar(&cad.File, "file", "", "archivo de la base de datos sqllite")
+	flags.StringVar(&cad.Sid, "sid", "", "sid de oracle")
+	flags.StringVar(&cad.Wallet, "wallet", "", "directorio del wallet de oracle")
+	flags.Parse(args)
+	if !utl.IsNilStr(*out) {
+		return fmt.Errorf("the -out flag is required")
+	}
+	if utl.FileExist(*out, false) && !*force {
+		return fmt.Errorf("the file %s already exists, use -force to replace it", *out)
+	}
+	switch {
+	case utl.IsNilStr(*iniPath):
+		var cnx db.StConect
+		err := cnx.ConfigINI(*iniPath)
+		if err != nil {
+			return err
+		}
+		cad = cnx.Conexion
+	case *interactive || !utl.IsNilStr(cad.TP):
+		cad = askConect()
+	}
+	if !cad.ValidCad() {
+		return fmt.Errorf("the connection is invalid")
+	}
+	pass, err := password(*key, "clave de encriptacion: ")
+	if err != nil {
+		return err
+	}
+	err = db.WriteDbFile(cad, pass, *out)
+	if err != nil {
+		return err
+	}
+	utl.PrintGreen("archivo %s creado\n", *out)
+	return nil
+}
+
+/*show : muestra un archivo .dbx en json con la clave oculta*/
+func show(args []string) error {
+	cad, _, err := readFile("show", args)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(cad.Masked(), "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+/*rekey : cambia la clave de encriptacion de un archivo .dbx*/
+func rekey(args []string) error {
+	flags := flag.NewFlagSet("rekey", flag.ExitOnError)
+	key := flags.String("key", "", "clave de encriptacion actual")
+	newKey := flags.String("newkey", "", "clave de encriptacion nueva")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return fmt.Errorf("the .dbx file is required")
+	}
+	path := flags.Arg(0)
+	pass, err := password(*key, "clave actual: ")
+	if err != nil {
+		return err
+	}
+	newPass := *newKey
+	if !utl.IsNilStr(newPass) {
+		newPass, err = ask("clave nueva: ")
+		if err != nil {
+			return err
+		}
+	}
+	if strings.TrimSpace(newPass) == "" {
+		return fmt.Errorf("the new key is empty")
+	}
+	err = db.RekeyDbFile(path, pass, newPass)
+	if err != nil {
+		return err
+	}
+	utl.PrintGreen("clave de %s actualizada\n", path)
+	return nil
+}
+
+/*test : prueba la conexion de un archivo .dbx*/
+func test(args []string) error {
+	cad, path, err := readFile("test", args)
+	if err != nil {
+		return err
+	}
+	cnx := db.StConect{Conexion: cad}
+	defer cnx.Close()
+	err = cnx.Con()
+	if err != nil {
+		return err
+	}
+	if !cnx.Test() {
+		return fmt.Errorf("the ping query of %s failed", path)
+	}
+	utl.PrintGreen("conexion %s correcta\n", path)
+	return nil
+}
+
+/*validate : valida los datos de la conexion de un archivo .dbx*/
+func validate(args []string) error {
+	cad, path, err := readFile("validate", args)
+	if err != nil {
+		return err
+	}
+	if _, ok := db.GetDialect(cad.TP); !ok {
+		return fmt.Errorf("the database type %s is not registered", cad.TP)
+	}
+	utl.PrintGreen("archivo %s valido (%s)\n", path, cad.TP)
+	return nil
+}
+
+/*readFile : lee los parametros -key y el archivo .dbx de un subcomando y lo desencripta*/
+func readFile(name string, args []string) (db.StCadConect, string, error) {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	key := flags.String("key", "", "clave de encriptacion")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return db.StCadConect{}, "", fmt.Errorf("the .dbx file is required")
+	}
+	path := flags.Arg(0)
+	pass, err := password(*key, "clave de encriptacion: ")
+	if err != nil {
+		return db.StCadConect{}, path, err
+	}
+	cad, err := db.ReadDbFile(path, pass)
+	return cad, path, err
+}
+
+/*password : clave de encriptacion del parametro, de DBXPASS o de la consola*/
+func password(key, prompt string) (string, error) {
+	if utl.IsNilStr(key) {
+		return key, nil
+	}
+	if env := os.Getenv(db.DBXPASS); utl.IsNilStr(env) {
+		return env, nil
+	}
+	return ask(prompt)
+}
+
+/*askConect : pide los datos de la conexion por consola*/
+func askConect() db.StCadConect {
+	var cad db.StCadConect
+	cad.TP = strings.ToUpper(askDef("tipo (POST,MYSQL,SQLSER,ORA,SQLLITE)", ""))
+	if cad.TP == db.SQLLite {
+		cad.File = askDef("archivo", "")
+		return cad
+	}
+	cad.Host = askDef("servidor", "localhost")
+	cad.Port, _ = strconv.Atoi(askDef("puerto", ""))
+	cad.User = askDef("usuario", "")
+	cad.Pass = askDef("clave", "")
+	cad.Name = askDef("base de datos", "")
+	cad.Sslmode = askDef("modo ssl", "")
+	if cad.TP == db.Ora {
+		cad.Sid = askDef("sid", "")
+		cad.Wallet = askDef("wallet", "")
+	}
+	return cad
+}
+
+/*askDef : pide un dato por consola con un valor por defecto*/
+func askDef(label, def string) string {
+	prompt := fmt.Sprintf("%s: ", label)
+	if utl.IsNilStr(def) {
+		prompt = fmt.Sprintf("%s [%s]: ", label, def)
+	}
+	vl, err := ask(prompt)
+	if err != nil || !utl.IsNilStr(vl) {
+		return def
+	}
+	return vl
+}
+
+/*ask : lee una linea de la consola*/
+func ask(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	line, err := stdin.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
@@ -1,10 +1,13 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/joho/godotenv"
@@ -23,26 +26,55 @@ import (
 )
 
 type (
-	/*StCadConect : Estructura para generar la cadena de  conexiones de base de datos */
+	/*StCadConect : Estructura para generar la cadena de  conexiones de base de datos
+	MaxOpen : maximo de conexiones abiertas del pool (0 sin limite)
+	MaxIdle : maximo de conexiones inactivas del pool (0 valor por defecto del driver)
+	MaxLifetime : segundos de vida maxima de una conexion (0 sin limite)
+	MaxIdleTime : segundos maximos que una conexion puede estar inactiva (0 sin limite)
+	Sid : oracle se conecta por SID en lugar del service name de Name
+	Wallet : oracle directorio del wallet para conexiones ssl
+	Retries : reintentos de la conexion y de las consultas que fallan con errores transitorios (0 sin reintentos)
+	RetryDelay : milisegundos de espera del primer reintento, se duplica en cada intento (0 usa RETRYDELAY)
+	RetryMaxDelay : milisegundos maximos de espera entre reintentos (0 usa RETRYMAXDELAY)
+	Replicas : replicas de lectura, los datos vacios se toman de la base principal (solo json)
+	ReplicaMode : REPLICAROUND o REPLICALATENCY para elegir la replica de las consultas (por defecto REPLICAROUND)
+	*/
 	StCadConect struct {
-		File    string `json:"filedb"  ini:"filedb"`
-		User    string `json:"userName" ini:"userName"`
-		Pass    string `json:"pass"   ini:"pass"`
-		Name    string `json:"name"  ini:"name"`
-		TP      string `json:"tp"    ini:"tp"`
-		Host    string `json:"host"    ini:"host"`
-		Port    int    `json:"port"  ini:"port"`
-		Sslmode string `json:"sslmode" ini:"sslmode"`
-	}
-	/*StConect : Estructura que contiene la conexion a x TP de base de datos.*/
+		File          string        `json:"filedb"  ini:"filedb"`
+		User          string        `json:"userName" ini:"userName"`
+		Pass          string        `json:"pass"   ini:"pass"`
+		Name          string        `json:"name"  ini:"name"`
+		TP            string        `json:"tp"    ini:"tp"`
+		Host          string        `json:"host"    ini:"host"`
+		Port          int           `json:"port"  ini:"port"`
+		Sslmode       string        `json:"sslmode" ini:"sslmode"`
+		MaxOpen       int           `json:"maxOpen" ini:"maxOpen"`
+		MaxIdle       int           `json:"maxIdle" ini:"maxIdle"`
+		MaxLifetime   int           `json:"maxLifetime" ini:"maxLifetime"`
+		MaxIdleTime   int           `json:"maxIdleTime" ini:"maxIdleTime"`
+		Sid           string        `json:"sid" ini:"sid"`
+		Wallet        string        `json:"wallet" ini:"wallet"`
+		Retries       int           `json:"retries" ini:"retries"`
+		RetryDelay    int           `json:"retryDelay" ini:"retryDelay"`
+		RetryMaxDelay int           `json:"retryMaxDelay" ini:"retryMaxDelay"`
+		Replicas      []StCadConect `json:"replicas" ini:"-"`
+		ReplicaMode   string        `json:"replicaMode" ini:"replicaMode"`
+	}
+	/*StConect : Estructura que contiene la conexion a x TP de base de datos.
+	Hooks : interceptores que reciben cada consulta y ejecucion, se agregan con AddHook
+	las consultas Query* se envian a las replicas de lectura si estan configuradas y las ejecuciones y
+	transacciones siempre a la base principal
+	shared : pool compartido de un ConnRegistry o de los hilos de ProcessParallel que Close no cierra*/
 	StConect struct {
 		Conexion     StCadConect
 		urlNative    string
 		DBGO         *sqlx.DB
-		DBTx         *sql.Tx
 		DBStmt       *sql.Stmt
 		backupScript string
 		Queries      map[string]string
+		Hooks        []StHook
+		replicas     *replicaSet
+		shared       bool
 	}
 )
 
@@ -53,27 +85,19 @@ func (p *StConect) SetBackupScript(sql string) {
 
 /*ExecBackup : ejecuta el querie backup */
 func (p *StConect) ExecBackup() error {
+	return p.ExecBackupCtx(context.Background())
+}
+
+/*ExecBackupCtx : ejecuta el querie backup con un contexto de cancelacion*/
+func (p *StConect) ExecBackupCtx(ctx context.Context) error {
 	if len(p.backupScript) <= 0 {
 		return fmt.Errorf("number of shares less than or equal to zeros")
 	}
-	err := p.Con()
-	if err != nil {
-		return err
-	}
-	tx := p.DBGO.MustBegin()
-	_, err = tx.Exec(p.backupScript)
-	if err != nil {
-		p.Close()
-		tx.Rollback()
-		return err
-	}
-	err = tx.Commit()
-	if err != nil {
-		p.Close()
-		tx.Rollback()
-		return err
-	}
-	return nil
+	return p.inTx(ctx, true, func(tx *txSent) error {
+		return runStmt(ctx, p.Hooks, newStmt(HOOKEXEC, p.backupScript, nil, nil), func(querie string, args []interface{}) (int64, error) {
+			return rowsAffected(tx.ExecContext(ctx, querie, args...))
+		})
+	})
 }
 
 /*SendSQL : envia un sql con los argumentos */
@@ -84,8 +108,27 @@ func (p *StConect) SendSQL(code string, args map[string]interface{}) StQuery {
 	}
 }
 
-/*Close : cierra las conexiones de base de datos intanciadas*/
+/*Close : cierra las conexiones de base de datos intanciadas y sus replicas,
+las conexiones compartidas de un ConnRegistry solo se cierran con CloseAll*/
 func (p *StConect) Close() error {
+	if p.shared {
+		return nil
+	}
+	return p.closePool()
+}
+
+/*release : cierra la base principal al terminar una llamada con indConect = false o con error,
+las replicas siguen abiertas hasta el Close de la conexion*/
+func (p *StConect) release() error {
+	if p.shared || p.DBGO == nil {
+		return nil
+	}
+	return p.DBGO.Close()
+}
+
+/*closePool : cierra el pool de la conexion y de sus replicas*/
+func (p *StConect) closePool() error {
+	p.closeReplicas()
 	if p.DBGO == nil {
 		return nil
 	}
@@ -98,22 +141,7 @@ func (p *StConect) Close() error {
 
 /*NamedIn : procesa los argumentos y sql para agarrar la clausula IN */
 func (p *StConect) NamedIn(query StQuery) (string, []interface{}, error) {
-	var (
-		sqltemp string
-		args    []interface{}
-		err     error
-	)
-	sqltemp, args, err = sqlx.Named(query.Querie, query.Args)
-	if err != nil {
-		return "", nil, err
-	}
-	sqltemp, args, err = sqlx.In(sqltemp, args...)
-	if err != nil {
-		return "", nil, err
-	}
-	sqltemp = p.DBGO.Rebind(sqltemp)
-
-	return sqltemp, args, err
+	return namedIn(p.DBGO, query)
 }
 
 /*Trim : Elimina los espacio en cualquier campo string */
@@ -125,6 +153,8 @@ func (p *StCadConect) Trim() {
 	p.TP = utl.Trim(p.TP)
 	p.Host = utl.Trim(p.Host)
 	p.Sslmode = utl.Trim(p.Sslmode)
+	p.Sid = utl.Trim(p.Sid)
+	p.Wallet = utl.Trim(p.Wallet)
 	if p.TP == Post && p.Sslmode == "" {
 		p.Sslmode = Ssmodes[0]
 	}
@@ -156,7 +186,18 @@ Ejemplo:
 	"host":"Localhost",
 	"Port":3000,
 	"sslmode":"",
-	"filedb":""
+	"filedb":"",
+	"maxOpen":20,
+	"maxIdle":5,
+	"maxLifetime":300,
+	"maxIdleTime":60,
+	"sid":"opcional oracle",
+	"wallet":"opcional oracle",
+	"retries":3,
+	"retryDelay":100,
+	"retryMaxDelay":5000,
+	"replicaMode":"opcional ROUNDROBIN o LATENCY",
+	"replicas":[{"host":"replica1"},{"host":"replica2"}]
 
 }
 */
@@ -229,6 +270,24 @@ host = Localhost
 sslmode = opcional
 
 filedb = opcional sqllite
+
+maxOpen = opcional maximo de conexiones abiertas
+
+maxIdle = opcional maximo de conexiones inactivas
+
+maxLifetime = opcional segundos de vida de una conexion
+
+maxIdleTime = opcional segundos de inactividad de una conexion
+
+sid = opcional oracle conexion por SID en lugar de name
+
+wallet = opcional oracle directorio del wallet
+
+retries = opcional reintentos con errores transitorios
+
+retryDelay = opcional milisegundos de espera del primer reintento
+
+retryMaxDelay = opcional milisegundos maximos de espera entre reintentos
 */
 func (p *StConect) ConfigINI(PathINI string) error {
 	if !utl.FileExt(PathINI, "INI") {
@@ -253,6 +312,15 @@ ENV PORTDB = 5433
 ENV HOSTDB = Localhost
 ENV SSLMODE = opcional
 ENV  FILEDB = opcional sqllite
+ENV MAXOPENDB = opcional maximo de conexiones abiertas
+ENV MAXIDLEDB = opcional maximo de conexiones inactivas
+ENV MAXLIFETIMEDB = opcional segundos de vida de una conexion
+ENV MAXIDLETIMEDB = opcional segundos de inactividad de una conexion
+ENV SIDDB = opcional oracle conexion por SID
+ENV WALLETDB = opcional oracle directorio del wallet
+ENV RETRIESDB = opcional reintentos con errores transitorios
+ENV RETRYDELAYDB = opcional milisegundos de espera del primer reintento
+ENV RETRYMAXDELAYDB = opcional milisegundos maximos de espera entre reintentos
 
 o en un archivo .env se colaca las variables
 */
@@ -272,6 +340,15 @@ func (p *StConect) ConfigENV() error {
 	cad.Host = os.Getenv("HOSTDB")
 	cad.Sslmode = os.Getenv("SSLMODE")
 	cad.File = os.Getenv("FILEDB")
+	cad.MaxOpen = utl.ToInt(os.Getenv("MAXOPENDB"))
+	cad.MaxIdle = utl.ToInt(os.Getenv("MAXIDLEDB"))
+	cad.MaxLifetime = utl.ToInt(os.Getenv("MAXLIFETIMEDB"))
+	cad.MaxIdleTime = utl.ToInt(os.Getenv("MAXIDLETIMEDB"))
+	cad.Sid = os.Getenv("SIDDB")
+	cad.Wallet = os.Getenv("WALLETDB")
+	cad.Retries = utl.ToInt(os.Getenv("RETRIESDB"))
+	cad.RetryDelay = utl.ToInt(os.Getenv("RETRYDELAYDB"))
+	cad.RetryMaxDelay = utl.ToInt(os.Getenv("RETRYMAXDELAYDB"))
 	if !cad.ValidCad() {
 		return fmt.Errorf("the config connect is invalid")
 	}
@@ -289,41 +366,108 @@ func (p *StCadConect) ToString() string {
 	return fmt.Sprintf(FORMATTOSTRCONECT, p.Pass, p.Host, p.Name, p.Port, p.Sslmode, p.TP, p.User, p.File)
 }
 
+/*Masked : copia de la conexion con las claves ocultas para mostrarla*/
+func (p *StCadConect) Masked() StCadConect {
+	cad := *p
+	if utl.IsNilStr(cad.Pass) {
+		cad.Pass = REDACTMASK
+	}
+	cad.Replicas = nil
+	for _, item := range p.Replicas {
+		cad.Replicas = append(cad.Replicas, item.Masked())
+	}
+	return cad
+}
+
 /*ValidCad : valida la cadena de conexion capturada */
 func (p *StCadConect) ValidCad() bool {
 	p.Trim()
 	if !validTp(p.TP) {
 		return false
 	}
-	if p.TP != SQLLite && (!utl.IsNilArrayStr(p.Pass, p.User, p.Name, p.TP, p.Host) || p.Port <= 0) {
+	switch p.TP {
+	case SQLLite:
+		if !utl.IsNilStr(p.File) {
+			return false
+		}
+	case Ora:
+		/*oracle acepta el service name en Name o el SID*/
+		if !utl.IsNilArrayStr(p.Pass, p.User, p.Host) || p.Port <= 0 || !utl.IsNilStr(p.Name+p.Sid) {
+			return false
+		}
+	default:
+		if !utl.IsNilArrayStr(p.Pass, p.User, p.Name, p.TP, p.Host) || p.Port <= 0 {
+			return false
+		}
+	}
+	if p.MaxOpen < 0 || p.MaxIdle < 0 || p.MaxLifetime < 0 || p.MaxIdleTime < 0 ||
+		p.Retries < 0 || p.RetryDelay < 0 || p.RetryMaxDelay < 0 {
 		return false
 	}
-	if p.TP == SQLLite && !utl.IsNilStr(p.File) {
+	if !utl.InStr(strings.ToUpper(strings.TrimSpace(p.ReplicaMode)), "", REPLICAROUND, REPLICALATENCY) {
 		return false
 	}
 	return true
 }
 
+/*SetPool : aplica la configuracion del pool de conexiones a una base de datos abierta*/
+func (p *StCadConect) SetPool(db *sqlx.DB) {
+	if db == nil {
+		return
+	}
+	if p.MaxOpen > 0 {
+		db.SetMaxOpenConns(p.MaxOpen)
+	}
+	if p.MaxIdle > 0 {
+		db.SetMaxIdleConns(p.MaxIdle)
+	}
+	if p.MaxLifetime > 0 {
+		db.SetConnMaxLifetime(time.Duration(p.MaxLifetime) * time.Second)
+	}
+	if p.MaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(time.Duration(p.MaxIdleTime) * time.Second)
+	}
+}
+
 /*Con : Crear una conexion ala base de datos configurada en la cadena.*/
 func (p *StConect) Con() error {
+	return p.ConCtx(context.Background())
+}
+
+/*ConCtx : Crear una conexion ala base de datos configurada en la cadena con un contexto de cancelacion,
+si la conexion falla con un error transitorio se reintenta hasta Retries veces.*/
+func (p *StConect) ConCtx(ctx context.Context) error {
+	return p.retry(ctx, func() error {
+		return p.connect(ctx)
+	})
+}
+
+/*connect : abre la conexion o la vuelve a abrir si el ping falla*/
+func (p *StConect) connect(ctx context.Context) error {
 	var (
 		err, errping error
 	)
+	p.initReplicas()
 	prefijo, cadena := p.urlConect()
 	if cadena == "" {
 		return fmt.Errorf("unsupported DB type")
 	}
 	if p.DBGO != nil {
-		errping = p.DBGO.Ping()
+		errping = p.DBGO.PingContext(ctx)
+	}
+	if p.shared && p.DBGO != nil {
+		/*el pool compartido no se reemplaza, database/sql vuelve a abrir las conexiones invalidas*/
+		return errping
 	}
 	if errping != nil || p.DBGO == nil {
 		if p.Conexion.TP == SQLLite && p.createDB() != nil {
 			return fmt.Errorf("the db is sqllite you need the file.d")
 		}
-		p.DBGO, err = sqlx.Connect(prefijo, cadena)
+		p.DBGO, err = sqlx.ConnectContext(ctx, prefijo, cadena)
 		if err != nil {
 			return err
 		}
+		p.Conexion.SetPool(p.DBGO)
 	}
 	return nil
 }
@@ -342,13 +486,21 @@ func (p *StConect) UpdateOrDelete(Data []StQuery) (int64, error) {
 	return 0, nil
 }
 
-/*ExecDatatable : ejecuta a nivel de base de datos una accione datable esta puede ser INSERT,DELETE,UPDATE*/
+/*ExecDatatable : ejecuta a nivel de base de datos una accione datable esta puede ser INSERT,DELETE,UPDATE,UPSERT*/
 func (p *StConect) ExecDatatable(data DataTable, acc string, indConect bool) error {
+	return p.ExecDatatableCtx(context.Background(), data, acc, indConect)
+}
+
+/*ExecDatatableCtx : igual que ExecDatatable pero con un contexto de cancelacion*/
+func (p *StConect) ExecDatatableCtx(ctx context.Context, data DataTable, acc string, indConect bool) error {
+	if data.GetTp() == "" {
+		data.SetTp(p.Conexion.TP)
+	}
 	queries, err := data.GenSQL(acc)
 	if err != nil {
 		return err
 	}
-	err = p.Exec(queries, indConect)
+	err = p.ExecCtx(ctx, queries, indConect)
 	if err != nil {
 		return err
 	}
@@ -357,91 +509,85 @@ func (p *StConect) ExecDatatable(data DataTable, acc string, indConect bool) err
 
 /*Exec :Ejecuta una accion de base de datos nativa con rollback*/
 func (p *StConect) Exec(Data []StQuery, indConect bool) error {
-	return p.execAux(Data, "", false, indConect)
+	return p.ExecCtx(context.Background(), Data, indConect)
+}
+
+/*ExecCtx :Ejecuta una accion de base de datos nativa con rollback y un contexto de cancelacion*/
+func (p *StConect) ExecCtx(ctx context.Context, Data []StQuery, indConect bool) error {
+	return p.execAux(ctx, Data, "", false, indConect)
 }
 
 /*ExecOne :Ejecuta un StQuery navito haciendo rollback con un error*/
 func (p *StConect) ExecOne(Data StQuery, indConect bool) error {
-	err := p.Con()
-	if err != nil {
-		return err
-	}
-	//Bloque de ejecucion
-	tx := p.DBGO.MustBegin()
-	_, err = tx.NamedExec(Data.Querie, Data.Args)
-	if err != nil {
-		p.Close()
-		tx.Rollback()
-		return err
-	}
-	err = tx.Commit()
-	if err != nil {
-		p.Close()
-		tx.Rollback()
-		return err
-	}
-	if !indConect {
-		p.Close()
-	}
-	return nil
+	return p.ExecOneCtx(context.Background(), Data, indConect)
+}
+
+/*ExecOneCtx :Ejecuta un StQuery navito haciendo rollback con un error o si se cancela el contexto*/
+func (p *StConect) ExecOneCtx(ctx context.Context, Data StQuery, indConect bool) error {
+	return p.inTx(ctx, indConect, func(tx *txSent) error {
+		return execExt(ctx, tx, p.Hooks, []StQuery{Data}, "", false)
+	})
 }
 
 /*ExecValid :Ejecuta una accion de base de datos nativa con rollback y validacion de insert e delete o que TP de accion es */
 func (p *StConect) ExecValid(Data []StQuery, tipacc string) error {
-	return p.execAux(Data, tipacc, true, false)
+	return p.ExecValidCtx(context.Background(), Data, tipacc)
+}
+
+/*ExecValidCtx : igual que ExecValid pero con un contexto de cancelacion*/
+func (p *StConect) ExecValidCtx(ctx context.Context, Data []StQuery, tipacc string) error {
+	return p.execAux(ctx, Data, tipacc, true, false)
 }
 
 /*ExecNative :  ejecuta la funcion nativa del paquete sql*/
 func (p *StConect) ExecNative(sql string, indConect bool, args ...interface{}) (sql.Result, error) {
-	if !utl.IsNilStr(sql) {
+	return p.ExecNativeCtx(context.Background(), sql, indConect, args...)
+}
+
+/*ExecNativeCtx :  ejecuta la funcion nativa del paquete sql con un contexto de cancelacion*/
+func (p *StConect) ExecNativeCtx(ctx context.Context, querie string, indConect bool, args ...interface{}) (sql.Result, error) {
+	if !utl.IsNilStr(querie) {
 		return nil, utl.StrErr("El querie esta vacio")
 	}
-	err := p.Con()
+	var rel sql.Result
+	err := p.inTx(ctx, indConect, func(tx *txSent) error {
+		return runStmt(ctx, p.Hooks, newStmt(HOOKEXEC, querie, args, nil), func(querie string, args []interface{}) (int64, error) {
+			var err error
+			rel, err = tx.ExecContext(ctx, querie, args...)
+			return rowsAffected(rel, err)
+		})
+	})
 	if err != nil {
 		return nil, err
 	}
-	tx := p.DBGO.MustBegin()
-	rel, err := tx.Exec(sql, args...)
-	if err != nil {
-		p.Close()
-		tx.Rollback()
-		return rel, err
-	}
-	err = tx.Commit()
-	if err != nil {
-		p.Close()
-		tx.Rollback()
-		return nil, err
-	}
-	if !indConect {
-		p.Close()
-	}
 	return rel, nil
 }
 
 /*Test : Valida si se puede conectar ala base de datos antes de un  uso.*/
 func (p *StConect) Test() bool {
-	prueba := new(StQuery)
-	switch p.Conexion.TP {
-	case Post, Mysql, Sqlser, SQLLite:
-		prueba.Querie = `SELECT 1`
-	case Ora:
-		prueba.Querie = `SELECT 1 FROM DUAL`
+	d, err := p.Dialect()
+	if err != nil {
+		return false
 	}
-	dato, err := p.QueryMap(*prueba, 1, false, true)
+	dato, err := p.QueryMap(StQuery{Querie: d.PingQuery()}, 1, false, true)
 	if err != nil || len(dato) <= 0 {
 		return false
 	}
 	return true
 }
 
-/*ValidTable : valida si la tabla a buscar existe*/
+/*ValidTable : valida si la tabla a buscar existe en la base principal*/
 func (p *StConect) ValidTable(table string) bool {
+	d, err := p.Dialect()
+	if err != nil {
+		return false
+	}
 	prueba := StQuery{
-		Querie: TESTTABLE[p.Conexion.TP],
+		Querie: d.TableExistsQuery(),
 		Args: map[string]interface{}{
 			"TABLENAME": table,
 		},
+		Primary: true,
 	}
 	dato, err := p.QueryMap(prueba, 1, false, true)
 	if err != nil || len(dato) <= 0 {
@@ -3,6 +3,7 @@ package database
 import (
 	"bytes"
 	"fmt"
+	"os"
 
 	utl "github.com/rafael180496/core-util/utility"
 	"gopkg.in/ini.v1"
@@ -72,3 +73,38 @@ func CreateDbFile(cnx StCadConect, pass, dir, name string) error {
 	}
 	return nil
 }
+
+/*ReadDbFile : desencripta un archivo .dbx regresando la conexion validada*/
+func ReadDbFile(path, pass string) (StCadConect, error) {
+	var cnx StConect
+	err := cnx.ConfigDBX(path, pass)
+	return cnx.Conexion, err
+}
+
+/*WriteDbFile : encripta la conexion y la guarda en path reemplazando el archivo si existe*/
+func WriteDbFile(cnx StCadConect, pass, path string) error {
+	data, err := CreateDBConect(cnx, pass)
+	if err != nil {
+		return err
+	}
+	temp := path + ".tmp"
+	err = os.WriteFile(temp, data, 0600)
+	if err != nil {
+		return err
+	}
+	err = os.Rename(temp, path)
+	if err != nil {
+		os.Remove(temp)
+		return err
+	}
+	return nil
+}
+
+/*RekeyDbFile : cambia la clave de un archivo .dbx*/
+func RekeyDbFile(path, pass, newPass string) error {
+	cnx, err := ReadDbFile(path, pass)
+	if err != nil {
+		return err
+	}
+	return WriteDbFile(cnx, newPass, path)
+}
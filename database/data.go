@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"encoding/json"
 	"strings"
 	"time"
@@ -18,13 +19,28 @@ type (
 		Data []byte `db:"data"`
 	}
 
-	/*StQuery : Estructura para ejecutar query de base de datos. */
+	/*StQuery : Estructura para ejecutar query de base de datos.
+	Timeout : tiempo maximo de ejecucion del query si es cero no tiene limite
+	Primary : la consulta se ejecuta en la base principal aunque la conexion tenga replicas*/
 	StQuery struct {
-		Querie string `json:"querie"`
-		Args   map[string]interface{}
+		Querie  string `json:"querie"`
+		Args    map[string]interface{}
+		Timeout time.Duration `json:"timeout"`
+		Primary bool          `json:"primary"`
 	}
 	/*StData : Estructura que extrae los datos de una consulta de base de datos tramformandola en map*/
 	StData map[string]interface{}
+	/*StPage : pagina de un query con los datos de paginacion, se puede enviar directo en StDataEnv.Data
+	Total y Pages son cero si no se pidio el conteo de filas
+	Next : indica si existe una pagina siguiente*/
+	StPage struct {
+		Data  []StData `json:"data"`
+		Page  int      `json:"page"`
+		Size  int      `json:"size"`
+		Total int64    `json:"total"`
+		Pages int64    `json:"pages"`
+		Next  bool     `json:"next"`
+	}
 )
 
 /*ToJSON : Convierte un  StSQLData a utl.JSON*/
@@ -43,6 +59,14 @@ func (p *StSQLData) Unmarshal(v interface{}) error {
 	return json.Unmarshal(p.Data, v)
 }
 
+/*withTimeout : genera el contexto de ejecucion del query aplicando el timeout si fue configurado*/
+func (p *StQuery) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if p.Timeout > 0 {
+		return context.WithTimeout(ctx, p.Timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
 /*NewStData : crea un Stdata de un map*/
 func NewStData(mp map[string]interface{}) StData {
 	var data StData = mp
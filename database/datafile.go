@@ -0,0 +1,427 @@
+package database
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	utl "github.com/rafael180496/core-util/utility"
+)
+
+type (
+	/*StFileOpt : opciones de importacion y exportacion de archivos
+	Format : CSV,JSON (arreglo) o NDJSON (un objeto por linea), en los *File se toma de la extension si esta vacio
+	Delimiter : separador del csv por defecto ,
+	NoHeader : el csv no tiene encabezado, las columnas se toman de Columns en orden
+	DateFormat : formato de las fechas al exportar por defecto time.RFC3339
+	Columns : columnas a exportar en orden, por defecto todas
+	Mapping : al importar renombra el encabezado o key del archivo a la columna del datatable,
+	si tiene datos solo se importan los campos mapeados*/
+	StFileOpt struct {
+		Format     string            `json:"format"`
+		Delimiter  rune              `json:"delimiter"`
+		NoHeader   bool              `json:"noHeader"`
+		DateFormat string            `json:"dateFormat"`
+		Columns    []string          `json:"columns"`
+		Mapping    map[string]string `json:"mapping"`
+	}
+	/*rowWriter : escribe filas en el formato configurado*/
+	rowWriter struct {
+		opt   StFileOpt
+		w     *bufio.Writer
+		csv   *csv.Writer
+		cols  []string
+		count int
+	}
+)
+
+/*Export : escribe las filas del datatable en CSV, JSON o NDJSON*/
+func (p *DataTable) Export(w io.Writer, opt StFileOpt) error {
+	cols := opt.Columns
+	if len(cols) <= 0 {
+		cols = p.fileCols()
+	}
+	writer, err := newRowWriter(w, opt, cols)
+	if err != nil {
+		return err
+	}
+	for _, row := range p.rows {
+		err = writer.write(row)
+		if err != nil {
+			return err
+		}
+	}
+	return writer.close()
+}
+
+/*ExportFile : escribe las filas del datatable en un archivo nuevo o lo reemplaza*/
+func (p *DataTable) ExportFile(path string, opt StFileOpt) error {
+	return createFile(path, &opt, func(w io.Writer) error {
+		return p.Export(w, opt)
+	})
+}
+
+/*ExportQuery : ejecuta un query escribiendo las filas en CSV, JSON o NDJSON sin cargarlas en memoria,
+regresa la cantidad de filas escritas
+	indConect = true deja la conexion abierta
+*/
+func (p *StConect) ExportQuery(w io.Writer, query StQuery, opt StFileOpt, indConect bool) (int, error) {
+	return p.ExportQueryCtx(context.Background(), w, query, opt, indConect)
+}
+
+/*ExportQueryCtx : igual que ExportQuery pero con un contexto de cancelacion*/
+func (p *StConect) ExportQueryCtx(ctx context.Context, w io.Writer, query StQuery, opt StFileOpt, indConect bool) (int, error) {
+	iter, err := p.QueryIterCtx(ctx, query, indConect)
+	if err != nil {
+		return 0, err
+	}
+	defer iter.Close()
+	cols := opt.Columns
+	if len(cols) <= 0 {
+		cols = iter.Columns()
+	}
+	writer, err := newRowWriter(w, opt, cols)
+	if err != nil {
+		return 0, err
+	}
+	for iter.Next() {
+		err = writer.write(iter.Data())
+		if err != nil {
+			return writer.count, err
+		}
+	}
+	if iter.Err() != nil {
+		return writer.count, iter.Err()
+	}
+	return writer.count, writer.close()
+}
+
+/*ExportQueryFile : igual que ExportQuery pero escribe en un archivo nuevo o lo reemplaza*/
+func (p *StConect) ExportQueryFile(path string, query StQuery, opt StFileOpt, indConect bool) (int, error) {
+	var count int
+	err := createFile(path, &opt, func(w io.Writer) error {
+		var err error
+		count, err = p.ExportQuery(w, query, opt, indConect)
+		return err
+	})
+	return count, err
+}
+
+/*
+ImportDataTable : lee un CSV, JSON o NDJSON creando un datatable listo para ExecDatatable,
+en el csv los campos vacios se cargan como nulos y todos los valores como texto,
+con SetSchema se convierten a los tipos de las columnas.
+*/
+func ImportDataTable(r io.Reader, table string, index []string, opt StFileOpt) (DataTable, error) {
+	var (
+		rows []StData
+		err  error
+	)
+	switch strings.ToUpper(opt.Format) {
+	case CSV:
+		rows, err = readCSV(r, opt)
+	case JSON:
+		rows, err = readJSON(r, opt, true)
+	case NDJSON:
+		rows, err = readJSON(r, opt, false)
+	default:
+		return DataTable{}, fmt.Errorf("invalid file format %s", opt.Format)
+	}
+	if err != nil {
+		return DataTable{}, err
+	}
+	var data DataTable
+	data.SetTable(table)
+	data.AddRows(rows...)
+	if len(rows) > 0 {
+		err = data.AddIndexs(index...)
+	}
+	return data, err
+}
+
+/*ImportDataTableFile : igual que ImportDataTable pero lee un archivo*/
+func ImportDataTableFile(path, table string, index []string, opt StFileOpt) (DataTable, error) {
+	if !utl.FileExist(path, false) {
+		return DataTable{}, fmt.Errorf("the file does not exist")
+	}
+	err := fileFormat(path, &opt)
+	if err != nil {
+		return DataTable{}, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return DataTable{}, err
+	}
+	defer file.Close()
+	return ImportDataTable(file, table, index, opt)
+}
+
+/*fileCols : columnas del datatable en el orden del esquema o todas las columnas de las filas ordenadas*/
+func (p *DataTable) fileCols() []string {
+	var cols []string
+	if len(p.schema) > 0 {
+		for _, col := range p.schema {
+			cols = append(cols, col.Name)
+		}
+		return cols
+	}
+	seen := make(map[string]bool)
+	for _, row := range p.rows {
+		for key := range row {
+			if !seen[key] {
+				seen[key] = true
+				cols = append(cols, key)
+			}
+		}
+	}
+	sort.Strings(cols)
+	return cols
+}
+
+/*createFile : crea o reemplaza el archivo tomando el formato de la extension si no se indico*/
+func createFile(path string, opt *StFileOpt, fn func(w io.Writer) error) error {
+	err := fileFormat(path, opt)
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = fn(file)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+/*fileFormat : asigna el formato de la extension del archivo (.csv,.json,.ndjson) si no se indico*/
+func fileFormat(path string, opt *StFileOpt) error {
+	if opt.Format != "" {
+		return nil
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case utl.EXT["CSV"]:
+		opt.Format = CSV
+	case utl.EXT["JSON"]:
+		opt.Format = JSON
+	case utl.EXT["NDJSON"]:
+		opt.Format = NDJSON
+	default:
+		return fmt.Errorf("invalid file format %s", filepath.Ext(path))
+	}
+	return nil
+}
+
+/*newRowWriter : prepara la escritura escribiendo el encabezado del csv o el inicio del arreglo json*/
+func newRowWriter(w io.Writer, opt StFileOpt, cols []string) (*rowWriter, error) {
+	opt.Format = strings.ToUpper(opt.Format)
+	if opt.DateFormat == "" {
+		opt.DateFormat = time.RFC3339
+	}
+	if len(cols) <= 0 {
+		return nil, fmt.Errorf("they have no loaded columns")
+	}
+	writer := &rowWriter{
+		opt:  opt,
+		w:    bufio.NewWriter(w),
+		cols: cols,
+	}
+	switch opt.Format {
+	case CSV:
+		writer.csv = csv.NewWriter(writer.w)
+		if opt.Delimiter != 0 {
+			writer.csv.Comma = opt.Delimiter
+		}
+		if !opt.NoHeader {
+			return writer, writer.csv.Write(cols)
+		}
+	case JSON:
+		_, err := writer.w.WriteString("[")
+		return writer, err
+	case NDJSON:
+	default:
+		return nil, fmt.Errorf("invalid file format %s", opt.Format)
+	}
+	return writer, nil
+}
+
+/*write : escribe una fila con las columnas configuradas*/
+func (p *rowWriter) write(row StData) error {
+	var err error
+	switch p.opt.Format {
+	case CSV:
+		record := make([]string, len(p.cols))
+		for i, col := range p.cols {
+			record[i] = utl.ToString(p.value(row[col]))
+		}
+		err = p.csv.Write(record)
+	default:
+		data := make(map[string]interface{})
+		for _, col := range p.cols {
+			data[col] = p.value(row[col])
+		}
+		var text []byte
+		text, err = json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		if p.opt.Format == JSON && p.count > 0 {
+			p.w.WriteString(",")
+		}
+		p.w.WriteString("\n")
+		_, err = p.w.Write(text)
+	}
+	if err != nil {
+		return err
+	}
+	p.count++
+	return nil
+}
+
+/*close : termina el arreglo json y vacia el buffer*/
+func (p *rowWriter) close() error {
+	switch p.opt.Format {
+	case CSV:
+		p.csv.Flush()
+		if err := p.csv.Error(); err != nil {
+			return err
+		}
+	case JSON:
+		p.w.WriteString("\n]\n")
+	case NDJSON:
+		p.w.WriteString("\n")
+	}
+	return p.w.Flush()
+}
+
+/*value : formatea las fechas y textos binarios para el archivo*/
+func (p *rowWriter) value(vl interface{}) interface{} {
+	switch data := vl.(type) {
+	case nil:
+		return nil
+	case time.Time:
+		return data.Format(p.opt.DateFormat)
+	case *time.Time:
+		if data == nil {
+			return nil
+		}
+		return data.Format(p.opt.DateFormat)
+	case []byte:
+		return string(data)
+	default:
+		return vl
+	}
+}
+
+/*readCSV : lee las filas de un csv con el encabezado o las columnas de las opciones*/
+func readCSV(r io.Reader, opt StFileOpt) ([]StData, error) {
+	var rows []StData
+	reader := csv.NewReader(r)
+	if opt.Delimiter != 0 {
+		reader.Comma = opt.Delimiter
+	}
+	header := opt.Columns
+	if !opt.NoHeader {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return rows, err
+		}
+		header = record
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+	if len(header) <= 0 {
+		return rows, fmt.Errorf("they have no loaded columns")
+	}
+	cols := mapCols(header, opt.Mapping)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return rows, err
+		}
+		if len(record) != len(cols) {
+			return rows, fmt.Errorf("row %d has %d fields and expected %d", len(rows)+1, len(record), len(cols))
+		}
+		row := make(StData)
+		for i, col := range cols {
+			if col == "" {
+				continue
+			}
+			row[col] = utl.ReturnIf(record[i] == "", nil, record[i])
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+/*readJSON : lee las filas de un arreglo json o de un objeto por linea (NDJSON)*/
+func readJSON(r io.Reader, opt StFileOpt, indArray bool) ([]StData, error) {
+	var rows []StData
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	if indArray {
+		token, err := dec.Token()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return rows, err
+		}
+		if delim, ok := token.(json.Delim); !ok || delim != '[' {
+			return rows, fmt.Errorf("the json is not an array")
+		}
+	}
+	for dec.More() {
+		var item map[string]interface{}
+		err := dec.Decode(&item)
+		if err != nil {
+			return rows, fmt.Errorf("row %d: %s", len(rows)+1, err.Error())
+		}
+		row := make(StData)
+		for key, vl := range item {
+			col := mapCols([]string{key}, opt.Mapping)[0]
+			if col == "" {
+				continue
+			}
+			if num, ok := vl.(json.Number); ok {
+				if data, err := num.Int64(); err == nil {
+					vl = data
+				} else {
+					vl, _ = num.Float64()
+				}
+			}
+			row[col] = vl
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+/*mapCols : aplica el mapeo de encabezados a columnas, los campos sin mapeo quedan vacios si existe un mapeo*/
+func mapCols(header []string, mapping map[string]string) []string {
+	cols := make([]string, len(header))
+	for i, item := range header {
+		item = strings.TrimSpace(item)
+		if len(mapping) <= 0 {
+			cols[i] = item
+			continue
+		}
+		cols[i] = mapping[item]
+	}
+	return cols
+}
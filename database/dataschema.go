@@ -0,0 +1,176 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	utl "github.com/rafael180496/core-util/utility"
+)
+
+type (
+	/*StViolation : valor de una fila del datatable que no cumple el esquema de columnas
+	Row : numero de fila iniciando en 1*/
+	StViolation struct {
+		Row    int    `json:"row"`
+		Column string `json:"column"`
+		Msj    string `json:"msj"`
+	}
+)
+
+/*Error : descripcion de la violacion con el numero de fila*/
+func (p StViolation) Error() string {
+	return fmt.Sprintf("row %d column %s: %s", p.Row, p.Column, p.Msj)
+}
+
+/*
+SetSchema : asigna el esquema de columnas del datatable (nombre, TpCore, nulos y largo maximo),
+los valores de las filas se convierten con FindTp al agregarse y GenSQL valida todas las filas
+antes de generar los sql. Las columnas se pueden leer de la base de datos con DescribeTable.
+*/
+func (p *DataTable) SetSchema(cols ...StColumn) {
+	p.schema = nil
+	for _, col := range cols {
+		col.Name = strings.ToUpper(utl.Trim(col.Name))
+		p.schema = append(p.schema, col)
+	}
+	for i := range p.rows {
+		p.rows[i] = p.coerceRow(p.rows[i])
+	}
+}
+
+/*GetSchema : Obtiene el esquema de columnas*/
+func (p *DataTable) GetSchema() []StColumn {
+	return p.schema
+}
+
+/*ValidRows : valida todas las filas con el esquema regresando cada violacion encontrada*/
+func (p *DataTable) ValidRows() []StViolation {
+	var result []StViolation
+	if len(p.schema) <= 0 {
+		return result
+	}
+	cols := make(map[string]StColumn)
+	for _, col := range p.schema {
+		cols[col.Name] = col
+	}
+	for i, row := range p.rows {
+		for _, key := range row.KeyColum() {
+			if _, ok := cols[key]; !ok {
+				result = append(result, StViolation{Row: i + 1, Column: key, Msj: "the column is not in the schema"})
+			}
+		}
+		for _, col := range p.schema {
+			vl, ok := row[col.Name]
+			if !ok || vl == nil {
+				if !col.Nullable {
+					result = append(result, StViolation{Row: i + 1, Column: col.Name, Msj: "the value is null"})
+				}
+				continue
+			}
+			if _, ok := coerceTp(vl, col.Tp); !ok {
+				result = append(result, StViolation{Row: i + 1, Column: col.Name, Msj: fmt.Sprintf("the value %v is not %s", vl, col.Tp)})
+				continue
+			}
+			if col.Length > 0 && col.Tp == STTP && utf8.RuneCountInString(utl.ToString(vl)) > col.Length {
+				result = append(result, StViolation{Row: i + 1, Column: col.Name, Msj: fmt.Sprintf("the value exceeds the length %d", col.Length)})
+			}
+		}
+	}
+	return result
+}
+
+/*Validate : valida las filas con el esquema regresando un error con todas las violaciones*/
+func (p *DataTable) Validate() error {
+	violations := p.ValidRows()
+	if len(violations) <= 0 {
+		return nil
+	}
+	var msj []string
+	for _, item := range violations {
+		msj = append(msj, item.Error())
+	}
+	return fmt.Errorf("the datatable %s has %d invalid values:\n%s", p.table, len(violations), strings.Join(msj, "\n"))
+}
+
+/*coerceRow : convierte los valores de la fila a los tipos del esquema,
+los valores que no se pueden convertir se dejan igual para reportarlos en ValidRows*/
+func (p *DataTable) coerceRow(row StData) StData {
+	for _, col := range p.schema {
+		vl, ok := row[col.Name]
+		if !ok || vl == nil {
+			continue
+		}
+		if data, ok := coerceTp(vl, col.Tp); ok {
+			row[col.Name] = data
+		}
+	}
+	return row
+}
+
+/*coerceTp : valida si el valor se puede convertir al TpCore y lo convierte con FindTp,
+los numeros usan float64 para no perder precision con el float32 de FindTp y los json se envian como texto*/
+func coerceTp(vl interface{}, tp TpCore) (interface{}, bool) {
+	vl = utl.AsignarPtr(vl)
+	text, isText := vl.(string)
+	if data, ok := vl.([]byte); ok {
+		text, isText = string(data), true
+	}
+	text = strings.TrimSpace(text)
+	switch tp {
+	case INTP:
+		switch data := vl.(type) {
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+			return FindTp(data, tp), true
+		case float32, float64:
+			num := utl.ToFloat64(data)
+			return FindTp(data, tp), num == math.Trunc(num)
+		}
+		if _, err := strconv.ParseInt(text, 10, 64); isText && err == nil {
+			return FindTp(text, tp), true
+		}
+	case FLTP:
+		switch data := vl.(type) {
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+			return utl.ToFloat64(data), true
+		}
+		if num, err := strconv.ParseFloat(text, 64); isText && err == nil {
+			return num, true
+		}
+	case BLTP:
+		switch data := vl.(type) {
+		case bool:
+			return data, true
+		case int, int8, int16, int32, int64:
+			num := utl.ToInt64(data)
+			return num != 0, num == 0 || num == 1
+		}
+		if data, err := strconv.ParseBool(text); isText && err == nil {
+			return data, true
+		}
+	case DTTP:
+		if data, ok := vl.(time.Time); ok {
+			return data, true
+		}
+		if _, err := utl.StringToDate(text); isText && err == nil {
+			return FindTp(text, tp), true
+		}
+	case STTP:
+		switch vl.(type) {
+		case map[string]interface{}, []interface{}:
+			return vl, false
+		}
+		return FindTp(vl, tp), true
+	case JSONTP:
+		if isText {
+			return text, json.Valid([]byte(text))
+		}
+		data, err := utl.NewJSON(vl)
+		return string(data), err == nil
+	}
+	return vl, false
+}
@@ -0,0 +1,158 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+type (
+	/*structField : columna leida de un campo de struct con el tag db*/
+	structField struct {
+		index     []int
+		col       string
+		pk        bool
+		omitempty bool
+	}
+)
+
+/*
+NewDataTableFromStructs : crea un datatable de un arreglo de structs leyendo el tag db de cada campo
+	db:"col" nombre de la columna, sin tag se usa el nombre del campo
+	db:"col,pk" agrega la columna a los indices para UPDATE,DELETE,UPSERT
+	db:"col,omitempty" omite la columna si esta vacia en todas las filas (llaves autoincrementables)
+	db:"-" omite el campo
+Ejemplo:
+	type Client struct {
+		ID   int    `db:"id,pk,omitempty"`
+		Name string `db:"name"`
+	}
+	data, err := NewDataTableFromStructs("clients", clients)
+*/
+func NewDataTableFromStructs[T any](table string, rows []T) (DataTable, error) {
+	var data DataTable
+	tp := reflect.TypeOf((*T)(nil)).Elem()
+	for tp.Kind() == reflect.Ptr {
+		tp = tp.Elem()
+	}
+	if tp.Kind() != reflect.Struct {
+		return data, fmt.Errorf("the type %s is not a struct", tp.String())
+	}
+	fields := readFields(tp, nil)
+	if len(fields) <= 0 {
+		return data, fmt.Errorf("the struct %s has no columns", tp.String())
+	}
+	var (
+		datarows []StData
+		index    []string
+	)
+	empty := make(map[string]bool)
+	for _, field := range fields {
+		empty[field.col] = field.omitempty
+	}
+	for _, item := range rows {
+		vl := reflect.ValueOf(item)
+		for vl.Kind() == reflect.Ptr {
+			if vl.IsNil() {
+				return data, fmt.Errorf("the row is nil")
+			}
+			vl = vl.Elem()
+		}
+		row := make(StData)
+		for _, field := range fields {
+			fv := vl.FieldByIndex(field.index)
+			if empty[field.col] && !fv.IsZero() {
+				empty[field.col] = false
+			}
+			row[field.col] = fieldValue(fv)
+		}
+		datarows = append(datarows, row)
+	}
+	for _, field := range fields {
+		if empty[field.col] {
+			for _, row := range datarows {
+				delete(row, field.col)
+			}
+			continue
+		}
+		if field.pk {
+			index = append(index, field.col)
+		}
+	}
+	data.SetTable(table)
+	data.AddRows(datarows...)
+	if len(datarows) > 0 {
+		err := data.AddIndexs(index...)
+		if err != nil {
+			return data, err
+		}
+	}
+	return data, nil
+}
+
+/*ExecDatatableStructs : crea el datatable de un arreglo de structs y ejecuta la accion INSERT,DELETE,UPDATE,UPSERT*/
+func ExecDatatableStructs[T any](cnx *StConect, table string, rows []T, acc string, indConect bool) error {
+	return ExecDatatableStructsCtx(context.Background(), cnx, table, rows, acc, indConect)
+}
+
+/*ExecDatatableStructsCtx : igual que ExecDatatableStructs pero con un contexto de cancelacion*/
+func ExecDatatableStructsCtx[T any](ctx context.Context, cnx *StConect, table string, rows []T, acc string, indConect bool) error {
+	data, err := NewDataTableFromStructs(table, rows)
+	if err != nil {
+		return err
+	}
+	return cnx.ExecDatatableCtx(ctx, data, acc, indConect)
+}
+
+/*readFields : lee los campos exportados de un struct incluyendo los structs embebidos sin tag*/
+func readFields(tp reflect.Type, parent []int) []structField {
+	var result []structField
+	for i := 0; i < tp.NumField(); i++ {
+		field := tp.Field(i)
+		index := append(append([]int{}, parent...), i)
+		tag, ok := field.Tag.Lookup("db")
+		if tag == "-" {
+			continue
+		}
+		if field.Anonymous && !ok && field.Type.Kind() == reflect.Struct {
+			result = append(result, readFields(field.Type, index)...)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		opts := strings.Split(tag, ",")
+		item := structField{
+			index: index,
+			col:   strings.TrimSpace(opts[0]),
+		}
+		if item.col == "" {
+			item.col = field.Name
+		}
+		for _, opt := range opts[1:] {
+			switch strings.TrimSpace(opt) {
+			case "pk":
+				item.pk = true
+			case "omitempty":
+				item.omitempty = true
+			}
+		}
+		result = append(result, item)
+	}
+	return result
+}
+
+/*fieldValue : valor del campo para los parametros del sql, los punteros nil se envian como NULL*/
+func fieldValue(fv reflect.Value) interface{} {
+	if fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			return nil
+		}
+		if _, ok := fv.Interface().(driver.Valuer); !ok {
+			fv = fv.Elem()
+		}
+	}
+	return fv.Interface()
+}
@@ -2,17 +2,24 @@ package database
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	utl "github.com/rafael180496/core-util/utility"
 )
 
 type (
-	/*DataTable : maneja un crud completo y genera script automaticos*/
+	/*DataTable : maneja un crud completo y genera script automaticos
+	tp : tipo de base de datos destino para los sql que dependen del motor
+	batch : cantidad de filas por insert en el modo por lotes (0 desactivado)
+	schema : columnas con su tipo para convertir y validar las filas (opcional)*/
 	DataTable struct {
-		table string
-		rows  []StData
-		index []string
+		table  string
+		rows   []StData
+		index  []string
+		tp     string
+		batch  int
+		schema []StColumn
 	}
 )
 
@@ -59,6 +66,27 @@ func (p *DataTable) SetTable(table string) {
 	p.table = strings.ToUpper(utl.Trim(table))
 }
 
+/*GetTp : Obtiene el tipo de base de datos destino*/
+func (p *DataTable) GetTp() string {
+	return p.tp
+}
+
+/*SetTp : Modifica el tipo de base de datos destino (ORA,POST,MYSQL,SQLSER,SQLLITE)*/
+func (p *DataTable) SetTp(tp string) {
+	p.tp = strings.ToUpper(utl.Trim(tp))
+}
+
+/*SetBatch : activa el modo de insert por lotes con la cantidad de filas por sentencia,
+la cantidad se ajusta al limite de parametros del tipo de base de datos, 0 lo desactiva*/
+func (p *DataTable) SetBatch(size int) {
+	p.batch = utl.ReturnIf(size < 0, 0, size).(int)
+}
+
+/*GetBatch : Obtiene la cantidad de filas por insert en el modo por lotes*/
+func (p *DataTable) GetBatch() int {
+	return p.batch
+}
+
 /*LenRows : Obtienen la cantidad de fila*/
 func (p *DataTable) LenRows() int {
 	return len(p.rows)
@@ -87,9 +115,9 @@ func (p *DataTable) AddRows(rows ...StData) {
 	}
 }
 
-/*AddRow : Agrega una fila */
+/*AddRow : Agrega una fila convirtiendo los valores si el datatable tiene esquema*/
 func (p *DataTable) AddRow(row StData) {
-	p.rows = append(p.rows, row.UpperKey())
+	p.rows = append(p.rows, p.coerceRow(row.UpperKey()))
 }
 
 /*AddIndex : agrega una llave para los delete o update*/
@@ -119,15 +147,25 @@ func (p *DataTable) GetRows() []StData {
 	return p.rows
 }
 
-/*GenSQL : genera acciones de base de datos mediante los siguientes comando INSERT,UPDATE,DELETE*/
+/*GenSQL : genera acciones de base de datos mediante los siguientes comando INSERT,UPDATE,DELETE,UPSERT
+si el datatable tiene SetBatch los INSERT se generan por lotes y si tiene SetSchema se validan todas las filas antes*/
 func (p *DataTable) GenSQL(accion string) ([]StQuery, error) {
+	err := p.Validate()
+	if err != nil {
+		return nil, err
+	}
 	switch accion {
 	case INSERT:
+		if p.batch > 0 {
+			return p.GenInsertsBatch()
+		}
 		return p.GenInserts()
 	case DELETE:
 		return p.GenDeletes()
 	case UPDATE:
 		return p.GenUpdates()
+	case UPSERT:
+		return p.GenUpserts()
 	default:
 		return nil, fmt.Errorf("invalid action datatable")
 	}
@@ -150,6 +188,49 @@ func (p *DataTable) GenInserts() ([]StQuery, error) {
 	return queries, nil
 }
 
+/*GenInsertsBatch : genera insert de varias filas por sentencia respetando el limite de parametros
+del tipo de base de datos configurado con SetTp, las columnas se toman de la primera fila y las columnas
+que falten en otra fila se envian como NULL explicito por lo que no se usa el valor por defecto de la tabla*/
+func (p *DataTable) GenInsertsBatch() ([]StQuery, error) {
+	var queries []StQuery
+	table := utl.Trim(p.GetTable())
+	if table == "" {
+		return queries, fmt.Errorf("they do not have a loaded table")
+	}
+	d, ok := GetDialect(p.tp)
+	if !ok {
+		return queries, fmt.Errorf("type database not supports")
+	}
+	maxparams := d.MaxParams()
+	cols, err := p.GetCols()
+	if err != nil {
+		return queries, err
+	}
+	sort.Strings(cols)
+	if !utl.ValidDuplidArrayStr(cols) {
+		return queries, fmt.Errorf("duplicate column")
+	}
+	size := p.batch
+	if size <= 0 {
+		size = p.LenRows()
+	}
+	if limit := (maxparams - 1) / len(cols); size > limit {
+		size = limit
+	}
+	if limit := MAXROWSINSERT[p.tp]; limit > 0 && size > limit {
+		size = limit
+	}
+	if size <= 0 {
+		return queries, fmt.Errorf("the number of columns exceeds the parameter limit")
+	}
+	rows := p.GetRows()
+	for i := 0; i < len(rows); i += size {
+		end := utl.ReturnIf(i+size > len(rows), len(rows), i+size).(int)
+		queries = append(queries, sqlinsertbatch(p.tp, table, cols, rows[i:end]))
+	}
+	return queries, nil
+}
+
 /*GenDeletes : genera los delete masivos para modificaciones de base de datos*/
 func (p *DataTable) GenDeletes() ([]StQuery, error) {
 	var queries []StQuery
@@ -186,7 +267,25 @@ func (p *DataTable) GenUpdates() ([]StQuery, error) {
 	return queries, nil
 }
 
-/*sqldinamic : genera los sql temporales para los crud*/
+/*GenUpserts : genera insert o update por llave usando los indices como llave de conflicto,
+el sql depende del tipo de base de datos configurado con SetTp*/
+func (p *DataTable) GenUpserts() ([]StQuery, error) {
+	var queries []StQuery
+	clone := *p
+	sqltemp, err := sqldinamic(clone, UPSERT)
+	if err != nil {
+		return queries, err
+	}
+	for _, quirie := range p.GetRows() {
+		queries = append(queries, StQuery{
+			Querie: sqltemp,
+			Args:   quirie,
+		})
+	}
+	return queries, nil
+}
+
+/*sqldinamic : genera los sql temporales para los crud con las columnas ordenadas para que el sql sea siempre igual*/
 func sqldinamic(data DataTable, acc string) (string, error) {
 	table := utl.Trim(data.GetTable())
 	if table == "" {
@@ -196,12 +295,16 @@ func sqldinamic(data DataTable, acc string) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	sort.Strings(cols)
 	if !utl.ValidDuplidArrayStr(cols) {
 		return "", fmt.Errorf("duplicate column")
 	}
 	if utl.InStr(acc, UPDATE, DELETE) && data.LenIndex() <= 0 {
 		return "", fmt.Errorf("duplicate column")
 	}
+	if acc == UPSERT && data.LenIndex() <= 0 {
+		return "", fmt.Errorf("they have no loaded index")
+	}
 	switch acc {
 	case INSERT:
 		sqltmp := sqlinsert(table, cols)
@@ -212,11 +315,67 @@ func sqldinamic(data DataTable, acc string) (string, error) {
 	case DELETE:
 		sqltmp := sqldelete(table, data.GetIndex())
 		return sqltmp, nil
+	case UPSERT:
+		return sqlupsert(data.GetTp(), table, cols, data.GetIndex())
 	default:
 		return "", nil
 
 	}
 }
+/*sqlupsert : genera el insert o update por llave segun el tipo de base de datos*/
+func sqlupsert(tp, table string, cols []string, indices []string) (string, error) {
+	values := utl.FilterExcl(cols, indices)
+	switch tp {
+	case Post, SQLLite:
+		sqltmp := fmt.Sprintf("%s ON CONFLICT (%s) DO", sqlinsert(table, cols), strings.Join(indices, ","))
+		if len(values) <= 0 {
+			return sqltmp + " NOTHING", nil
+		}
+		return fmt.Sprintf("%s UPDATE SET %s", sqltmp, sqlassign(values, "%s = EXCLUDED.%s")), nil
+	case Mysql:
+		if len(values) <= 0 {
+			values = indices[:1]
+		}
+		return fmt.Sprintf("%s ON DUPLICATE KEY UPDATE %s", sqlinsert(table, cols), sqlassign(values, "%s = VALUES(%s)")), nil
+	case Sqlser, Ora:
+		var (
+			src  []string
+			cond []string
+			ins  []string
+		)
+		for _, item := range cols {
+			src = append(src, fmt.Sprintf(":%s AS %s", item, item))
+			ins = append(ins, "SRC."+item)
+		}
+		for _, item := range indices {
+			cond = append(cond, fmt.Sprintf("TGT.%s = SRC.%s", item, item))
+		}
+		using := fmt.Sprintf("SELECT %s", strings.Join(src, ", "))
+		alias := "AS "
+		if tp == Ora {
+			using += " FROM DUAL"
+			alias = ""
+		}
+		sqltmp := fmt.Sprintf("MERGE INTO %s %sTGT USING (%s) %sSRC ON (%s)", table, alias, using, alias, strings.Join(cond, " AND "))
+		if len(values) > 0 {
+			sqltmp = fmt.Sprintf("%s WHEN MATCHED THEN UPDATE SET %s", sqltmp, sqlassign(values, "TGT.%s = SRC.%s"))
+		}
+		sqltmp = fmt.Sprintf("%s WHEN NOT MATCHED THEN INSERT (%s) VALUES (%s)", sqltmp, strings.Join(cols, ","), strings.Join(ins, ","))
+		return utl.ReturnIf(tp == Sqlser, sqltmp+";", sqltmp).(string), nil
+	default:
+		return "", fmt.Errorf("type database not supports")
+	}
+}
+
+/*sqlassign : genera las asignaciones de columnas separadas por coma con el formato enviado*/
+func sqlassign(cols []string, format string) string {
+	var items []string
+	for _, item := range cols {
+		items = append(items, fmt.Sprintf(format, item, item))
+	}
+	return strings.Join(items, ", ")
+}
+
 func sqldelete(table string, indices []string) string {
 	sqltmp := fmt.Sprintf("DELETE FROM  %s", table)
 	sqltmp = sqlConditional(sqltmp, indices)
@@ -243,6 +402,38 @@ func sqlConditional(sqltmp string, indices []string) string {
 	return sqltmp
 }
 
+/*sqlinsertbatch : genera un insert de varias filas renombrando los parametros por fila*/
+func sqlinsertbatch(tp, table string, cols []string, rows []StData) StQuery {
+	var (
+		values []string
+		args   = make(map[string]interface{})
+	)
+	for i, row := range rows {
+		params := make([]string, len(cols))
+		for j, col := range cols {
+			name := fmt.Sprintf("R%d_%s", i, col)
+			params[j] = ":" + name
+			args[name] = row[col]
+		}
+		values = append(values, fmt.Sprintf("(%s)", strings.Join(params, ",")))
+	}
+	colsql := strings.Join(cols, ",")
+	if tp == Ora {
+		sqltmp := "INSERT ALL"
+		for _, item := range values {
+			sqltmp = fmt.Sprintf("%s INTO %s (%s) VALUES %s", sqltmp, table, colsql, item)
+		}
+		return StQuery{
+			Querie: sqltmp + " SELECT 1 FROM DUAL",
+			Args:   args,
+		}
+	}
+	return StQuery{
+		Querie: fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", table, colsql, strings.Join(values, ",")),
+		Args:   args,
+	}
+}
+
 func sqlinsert(table string, cols []string) string {
 	sqltmp := fmt.Sprintf("INSERT INTO %s (", table)
 	for i, item := range cols {
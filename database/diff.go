@@ -0,0 +1,185 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	utl "github.com/rafael180496/core-util/utility"
+)
+
+type (
+	/*StDiffReport : reporte de los cambios que necesita la tabla de salida para quedar igual a la entrada
+	Samples : llaves de ejemplo por accion INSERT,UPDATE,DELETE (maximo DIFFSAMPLE)
+	Queries : sql generados con GenSQL en el orden que se ejecutan*/
+	StDiffReport struct {
+		Table     string              `json:"table"`
+		Inserts   int                 `json:"inserts"`
+		Updates   int                 `json:"updates"`
+		Deletes   int                 `json:"deletes"`
+		Unchanged int                 `json:"unchanged"`
+		Samples   map[string][]StData `json:"samples"`
+		DryRun    bool                `json:"dryRun"`
+		Queries   []StQuery           `json:"-"`
+	}
+)
+
+/*
+DiffExt : compara las filas del extractor con la tabla de salida por las columnas Index y genera
+solo los INSERT,UPDATE y DELETE necesarios, si dryRun es true regresa el reporte sin ejecutar los cambios,
+si no los ejecuta en una sola transaccion de la salida. Solo se comparan las columnas del extractor.
+*/
+func (p *StMerge) DiffExt(v StExt, dryRun bool) (StDiffReport, error) {
+	report := StDiffReport{
+		Table:   strings.ToUpper(utl.Trim(v.TableNameOut)),
+		Samples: make(map[string][]StData),
+		DryRun:  dryRun,
+	}
+	if len(v.Index) <= 0 {
+		return report, fmt.Errorf("they have no loaded index")
+	}
+	index := make([]string, len(v.Index))
+	for i, col := range v.Index {
+		index[i] = strings.ToUpper(col)
+	}
+	cnxIn := p.CnxIn
+	defer cnxIn.release()
+	rowsIn, err := cnxIn.QueryMap(v.SQLIn, 0, true, false)
+	if err != nil {
+		return report, err
+	}
+	rowsIn, err = v.TransformRows(rowsIn)
+	if err != nil {
+		return report, err
+	}
+	cnxOut := p.CnxOut
+	defer cnxOut.release()
+	rowsOut, err := cnxOut.QueryMap(StQuery{Querie: fmt.Sprintf(`SELECT * FROM %s`, report.Table), Primary: true}, 0, true, false)
+	if err != nil {
+		return report, err
+	}
+	src := NewDataTable(report.Table, rowsIn, nil)
+	target := NewDataTable(report.Table, rowsOut, nil)
+	cols := fillCols(src.GetRows())
+	current := make(map[string]StData)
+	for _, row := range target.GetRows() {
+		current[diffKey(row, index)] = row
+	}
+	var ins, upd, del []StData
+	seen := make(map[string]bool)
+	for _, row := range src.GetRows() {
+		key := diffKey(row, index)
+		if seen[key] {
+			return report, fmt.Errorf("duplicate key %s in the extractor %s", strings.ReplaceAll(key, "\x1f", ","), report.Table)
+		}
+		seen[key] = true
+		old, ok := current[key]
+		switch {
+		case !ok:
+			ins = append(ins, row)
+		case !diffEqual(row, old, cols):
+			upd = append(upd, row)
+		default:
+			report.Unchanged++
+		}
+	}
+	for _, row := range target.GetRows() {
+		if !seen[diffKey(row, index)] {
+			del = append(del, row.keys(index))
+		}
+	}
+	report.Inserts, report.Updates, report.Deletes = len(ins), len(upd), len(del)
+	for _, item := range []struct {
+		acc  string
+		rows []StData
+	}{{DELETE, del}, {UPDATE, upd}, {INSERT, ins}} {
+		if len(item.rows) <= 0 {
+			continue
+		}
+		for i := 0; i < len(item.rows) && i < DIFFSAMPLE; i++ {
+			report.Samples[item.acc] = append(report.Samples[item.acc], item.rows[i].keys(index))
+		}
+		data := NewDataTable(report.Table, item.rows, nil)
+		err = data.AddIndexs(index...)
+		if err != nil {
+			return report, err
+		}
+		data.SetTp(cnxOut.Conexion.TP)
+		if item.acc == INSERT {
+			data.SetBatch(p.Batch)
+		}
+		queries, err := data.GenSQL(item.acc)
+		if err != nil {
+			return report, err
+		}
+		report.Queries = append(report.Queries, queries...)
+	}
+	if dryRun || len(report.Queries) <= 0 {
+		return report, nil
+	}
+	return report, cnxOut.Exec(report.Queries, true)
+}
+
+/*keys : copia solo las columnas de la llave de la fila*/
+func (p StData) keys(index []string) StData {
+	data := make(StData)
+	for _, col := range index {
+		data[col] = p[col]
+	}
+	return data
+}
+
+/*fillCols : agrega como nulas las columnas que faltan en algunas filas para que todas tengan las mismas columnas*/
+func fillCols(rows []StData) []string {
+	var cols []string
+	seen := make(map[string]bool)
+	for _, row := range rows {
+		for key := range row {
+			if !seen[key] {
+				seen[key] = true
+				cols = append(cols, key)
+			}
+		}
+	}
+	for _, row := range rows {
+		for _, col := range cols {
+			if _, ok := row[col]; !ok {
+				row[col] = nil
+			}
+		}
+	}
+	return cols
+}
+
+/*diffKey : arma la llave de comparacion de una fila con las columnas del indice*/
+func diffKey(row StData, index []string) string {
+	values := make([]string, len(index))
+	for i, col := range index {
+		values[i] = diffValue(row[col])
+	}
+	return strings.Join(values, "\x1f")
+}
+
+/*diffEqual : compara las columnas de la entrada con la fila actual de la salida*/
+func diffEqual(row, old StData, cols []string) bool {
+	for _, col := range cols {
+		if diffValue(row[col]) != diffValue(old[col]) {
+			return false
+		}
+	}
+	return true
+}
+
+/*diffValue : normaliza un valor para comparar tipos distintos del mismo dato (int64,float64,texto,fechas)*/
+func diffValue(vl interface{}) string {
+	switch data := vl.(type) {
+	case nil:
+		return "\x00"
+	case time.Time:
+		return data.UTC().Format(time.RFC3339Nano)
+	case []byte:
+		return string(data)
+	default:
+		return utl.ToString(data)
+	}
+}
@@ -0,0 +1,183 @@
+package database
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	utl "github.com/rafael180496/core-util/utility"
+)
+
+type (
+	/*StStmt : sentencia que reciben los hooks de un StConect
+	Kind : HOOKQUERY o HOOKEXEC
+	SQL, Args : sql y argumentos finales despues del rebind de NamedIn
+	Named : argumentos originales del StQuery (nil en las funciones nativas)
+	Rows : filas leidas o afectadas, -1 si no se conoce (QueryRows y QueryNative)*/
+	StStmt struct {
+		Kind     string
+		SQL      string
+		Args     []interface{}
+		Named    map[string]interface{}
+		Duration time.Duration
+		Rows     int64
+		Err      error
+	}
+	/*StHook : interceptor de las consultas y ejecuciones de un StConect
+	Before : se ejecuta antes de la sentencia, puede reescribir SQL y Args o regresar un error para cancelarla
+	After : recibe la sentencia con la duracion, filas y error, los hooks se ejecutan en el orden en que se agregaron*/
+	StHook interface {
+		Before(ctx context.Context, stmt *StStmt) error
+		After(ctx context.Context, stmt *StStmt)
+	}
+	/*StSlowLog : hook que escribe en un StLog las sentencias que tardan mas que Threshold o terminan con error,
+	para ocultar argumentos se debe agregar StRedact antes*/
+	StSlowLog struct {
+		Log       *utl.StLog
+		Threshold time.Duration
+		mu        sync.Mutex
+	}
+	/*StRedact : hook que reemplaza los argumentos por Mask en los hooks siguientes sin cambiar lo que se ejecuta,
+	si Names esta vacio oculta todos los argumentos si no solo los argumentos con esos nombres*/
+	StRedact struct {
+		Names []string
+		Mask  string
+	}
+)
+
+/*AddHook : agrega hooks a la conexion*/
+func (p *StConect) AddHook(hooks ...StHook) {
+	p.Hooks = append(p.Hooks, hooks...)
+	p.hookReplicas()
+}
+
+/*NewSlowLog : crea un hook de sentencias lentas que escribe en el log*/
+func NewSlowLog(log *utl.StLog, threshold time.Duration) *StSlowLog {
+	return &StSlowLog{
+		Log:       log,
+		Threshold: threshold,
+	}
+}
+
+/*NewRedact : crea un hook que oculta los argumentos con los nombres enviados o todos si no se envian*/
+func NewRedact(names ...string) *StRedact {
+	return &StRedact{
+		Names: names,
+		Mask:  REDACTMASK,
+	}
+}
+
+/*Before : no modifica la sentencia*/
+func (p *StSlowLog) Before(ctx context.Context, stmt *StStmt) error {
+	return nil
+}
+
+/*After : escribe la sentencia en el log si es lenta o tiene error*/
+func (p *StSlowLog) After(ctx context.Context, stmt *StStmt) {
+	if p.Log == nil || (stmt.Duration < p.Threshold && stmt.Err == nil) {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if stmt.Err != nil {
+		p.Log.Printf("%s %s duration:%s args:%v error:%s\n", stmt.Kind, stmt.SQL, stmt.Duration, stmt.Args, stmt.Err.Error())
+		return
+	}
+	p.Log.Printf("%s %s duration:%s rows:%d args:%v\n", stmt.Kind, stmt.SQL, stmt.Duration, stmt.Rows, stmt.Args)
+}
+
+/*Before : no modifica la sentencia para que se ejecute con los argumentos reales*/
+func (p *StRedact) Before(ctx context.Context, stmt *StStmt) error {
+	return nil
+}
+
+/*After : reemplaza los argumentos ocultos en la sentencia que reciben los hooks siguientes*/
+func (p *StRedact) After(ctx context.Context, stmt *StStmt) {
+	mask := utl.ReturnIf(utl.IsNilStr(p.Mask), p.Mask, REDACTMASK).(string)
+	var hidden []interface{}
+	named := make(map[string]interface{})
+	for k, vl := range stmt.Named {
+		if len(p.Names) > 0 && !inCols(k, p.Names) {
+			named[k] = vl
+			continue
+		}
+		named[k] = mask
+		hidden = append(hidden, vl)
+	}
+	args := make([]interface{}, len(stmt.Args))
+	for i, vl := range stmt.Args {
+		args[i] = vl
+		if len(p.Names) <= 0 {
+			args[i] = mask
+			continue
+		}
+		for _, item := range hidden {
+			if reflect.DeepEqual(vl, item) {
+				args[i] = mask
+				break
+			}
+		}
+	}
+	if stmt.Named != nil {
+		stmt.Named = named
+	}
+	stmt.Args = args
+}
+
+/*newStmt : crea la sentencia de los hooks*/
+func newStmt(kind, sql string, args []interface{}, named map[string]interface{}) *StStmt {
+	return &StStmt{
+		Kind:  kind,
+		SQL:   sql,
+		Args:  args,
+		Named: named,
+		Rows:  -1,
+	}
+}
+
+/*hookBefore : ejecuta Before de los hooks, se detiene en el primer error*/
+func hookBefore(ctx context.Context, hooks []StHook, stmt *StStmt) error {
+	for _, hook := range hooks {
+		err := hook.Before(ctx, stmt)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*hookAfter : completa la sentencia y ejecuta After de los hooks*/
+func hookAfter(ctx context.Context, hooks []StHook, stmt *StStmt, start time.Time, rows int64, err error) {
+	if len(hooks) <= 0 {
+		return
+	}
+	stmt.Duration = time.Since(start)
+	stmt.Rows = rows
+	stmt.Err = err
+	for _, hook := range hooks {
+		hook.After(ctx, stmt)
+	}
+}
+
+/*runStmt : ejecuta una sentencia pasando por los hooks, fn recibe el sql y argumentos finales
+y regresa las filas leidas o afectadas*/
+func runStmt(ctx context.Context, hooks []StHook, stmt *StStmt, fn func(querie string, args []interface{}) (int64, error)) error {
+	err := hookBefore(ctx, hooks, stmt)
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	rows, err := fn(stmt.SQL, stmt.Args)
+	hookAfter(ctx, hooks, stmt, start, rows, err)
+	return err
+}
+
+/*lenRows : cantidad de filas de un destino de QueryStruct*/
+func lenRows(dest interface{}) int64 {
+	vl := reflect.Indirect(reflect.ValueOf(dest))
+	if vl.Kind() == reflect.Slice {
+		return int64(vl.Len())
+	}
+	return 1
+}
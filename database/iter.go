@@ -0,0 +1,145 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type (
+	/*StIter : iterador de filas de un query que escanea cada fila al llamar Next,
+	se debe llamar Close al terminar aunque la lectura se detenga antes del final
+	Ejemplo:
+	iter, err := cnx.QueryIter(query, false)
+	defer iter.Close()
+	for iter.Next() {
+		data := iter.Data()
+	}
+	err = iter.Err()
+	*/
+	StIter struct {
+		rows      *sqlx.Rows
+		columns   []string
+		scan      func() (StData, error)
+		data      StData
+		err       error
+		cancel    context.CancelFunc
+		cnx       *StConect
+		indConect bool
+		ctx       context.Context
+		stmt      *StStmt
+		start     time.Time
+		count     int64
+	}
+)
+
+/*QueryIter : Ejecuta un query en la base de datos y devuelve un iterador que lee las filas una por una
+	indConect = true deja la conexion abierta al cerrar el iterador
+*/
+func (p *StConect) QueryIter(query StQuery, indConect bool) (*StIter, error) {
+	return p.QueryIterCtx(context.Background(), query, indConect)
+}
+
+/*QueryIterCtx : igual que QueryIter pero con un contexto de cancelacion,
+el Timeout del StQuery se aplica hasta que se cierra el iterador y los hooks reciben
+la duracion y las filas leidas al cerrarlo*/
+func (p *StConect) QueryIterCtx(ctx context.Context, query StQuery, indConect bool) (*StIter, error) {
+	if replica := p.replica(ctx, query); replica != nil {
+		return replica.QueryIterCtx(ctx, query, indConect)
+	}
+	err := p.ConCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sqltemp, args, err := p.NamedIn(query)
+	if err != nil {
+		p.release()
+		return nil, err
+	}
+	stmt := newStmt(HOOKQUERY, sqltemp, args, query.Args)
+	err = hookBefore(ctx, p.Hooks, stmt)
+	if err != nil {
+		p.release()
+		return nil, err
+	}
+	start := time.Now()
+	qctx, cancel := query.withTimeout(ctx)
+	filas, err := p.DBGO.QueryxContext(qctx, stmt.SQL, stmt.Args...)
+	if err != nil {
+		hookAfter(ctx, p.Hooks, stmt, start, -1, err)
+		cancel()
+		p.release()
+		return nil, err
+	}
+	columns, scan, err := rowScanner(filas)
+	if err != nil {
+		hookAfter(ctx, p.Hooks, stmt, start, 0, err)
+		filas.Close()
+		cancel()
+		p.release()
+		return nil, err
+	}
+	return &StIter{
+		rows:      filas,
+		columns:   columns,
+		scan:      scan,
+		cancel:    cancel,
+		cnx:       p,
+		indConect: indConect,
+		ctx:       ctx,
+		stmt:      stmt,
+		start:     start,
+	}, nil
+}
+
+/*Next : avanza a la siguiente fila regresando false al terminar o si ocurre un error,
+al terminar cierra el iterador*/
+func (p *StIter) Next() bool {
+	if p.rows == nil || p.err != nil {
+		return false
+	}
+	if !p.rows.Next() {
+		p.err = p.rows.Err()
+		p.Close()
+		return false
+	}
+	p.data, p.err = p.scan()
+	if p.err != nil {
+		p.Close()
+		return false
+	}
+	p.count++
+	return true
+}
+
+/*Data : envia la fila actual*/
+func (p *StIter) Data() StData {
+	return p.data
+}
+
+/*Columns : envia el nombre de las columnas del query en orden*/
+func (p *StIter) Columns() []string {
+	return p.columns
+}
+
+/*Err : envia el error que detuvo la lectura*/
+func (p *StIter) Err() error {
+	return p.err
+}
+
+/*Close : cierra las filas y la conexion si indConect es false,
+se puede llamar varias veces*/
+func (p *StIter) Close() error {
+	if p.rows == nil {
+		return nil
+	}
+	err := p.rows.Close()
+	p.rows = nil
+	p.cancel()
+	hookAfter(p.ctx, p.cnx.Hooks, p.stmt, p.start, p.count, p.err)
+	if !p.indConect {
+		p.cnx.release()
+	}
+	return err
+}
@@ -0,0 +1,373 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	utl "github.com/rafael180496/core-util/utility"
+	"gopkg.in/ini.v1"
+)
+
+type (
+	/*
+		StJob : job etl declarado en un archivo json o ini que se convierte en un StMerge
+		In, Out : rutas de las conexiones de entrada y salida (.dbx, .json o .ini)
+		PassEnv : variable de entorno con la llave de los .dbx si no se envia en Run (por defecto DBXPASS)
+		Mode : modo de carga JOBPROCESS o JOBPARALLEL (por defecto JOBPROCESS)
+		State : archivo json donde se guardan las marcas de los extractores incrementales
+		Report : directorio donde se escribe el reporte json de cada ejecucion
+		DelIn, DelOut : scripts de borrado que se ejecutan antes de la carga
+
+		Ejemplo json:
+
+		{
+			"name": "clients",
+			"in": "config/in.dbx",
+			"out": "config/out.dbx",
+			"mode": "parallel",
+			"report": "logs/jobs",
+			"delout": ["DELETE FROM CLIENTS"],
+			"extractors": [
+				{"SQLIn": {"querie": "SELECT * FROM CLIENTS"}, "TableNameOut": "CLIENTS", "Index": ["ID"]}
+			]
+		}
+
+		Ejemplo ini, cada extractor es una seccion ext.<nombre> y los pasos de transformacion solo se
+		pueden declarar en json:
+
+		[job]
+		name = clients
+		in = config/in.dbx
+		out = config/out.dbx
+
+		[delout]
+		clients = DELETE FROM CLIENTS
+
+		[ext.clients]
+		sqlin = SELECT * FROM CLIENTS
+		table = CLIENTS
+		index = ID
+	*/
+	StJob struct {
+		Name       string   `json:"name" ini:"name"`
+		In         string   `json:"in" ini:"in"`
+		Out        string   `json:"out" ini:"out"`
+		PassEnv    string   `json:"passenv" ini:"passenv"`
+		Mode       string   `json:"mode" ini:"mode"`
+		Batch      int      `json:"batch" ini:"batch"`
+		Workers    int      `json:"workers" ini:"workers"`
+		Chunk      int      `json:"chunk" ini:"chunk"`
+		DryRun     bool     `json:"dryrun" ini:"dryrun"`
+		State      string   `json:"state" ini:"state"`
+		Report     string   `json:"report" ini:"report"`
+		DelIn      []string `json:"delin" ini:"-"`
+		DelOut     []string `json:"delout" ini:"-"`
+		Extractors []StExt  `json:"extractors" ini:"-"`
+	}
+	/*StJobReport : reporte de la ejecucion de un job
+	Tables : filas cargadas por cada extractor
+	Diffs : reportes de los extractores Diff*/
+	StJobReport struct {
+		Job     string         `json:"job"`
+		Mode    string         `json:"mode"`
+		Status  string         `json:"status"`
+		Error   string         `json:"error,omitempty"`
+		Start   time.Time      `json:"start"`
+		End     time.Time      `json:"end"`
+		Elapsed time.Duration  `json:"elapsed"`
+		Tables  []StProgress   `json:"tables"`
+		Diffs   []StDiffReport `json:"diffs"`
+	}
+	/*stJobExt : extractor de un job en formato ini*/
+	stJobExt struct {
+		SQLIn     string   `ini:"sqlin"`
+		Table     string   `ini:"table"`
+		Index     []string `ini:"index" delim:","`
+		Watermark string   `ini:"watermark"`
+		Diff      bool     `ini:"diff"`
+	}
+)
+
+/*LoadJob : lee un job de un archivo .json o .ini*/
+func LoadJob(path string) (StJob, error) {
+	var (
+		job StJob
+		err error
+	)
+	switch {
+	case utl.FileExt(path, "JSON"):
+		job, err = readJobJSON(path)
+	case utl.FileExt(path, "INI"):
+		job, err = readJobIni(path)
+	default:
+		return job, fmt.Errorf("the job file %s does not exist", path)
+	}
+	if err != nil {
+		return job, err
+	}
+	return job, job.Valid()
+}
+
+/*RunJobFile : lee y ejecuta un job de un archivo .json o .ini*/
+func RunJobFile(ctx context.Context, path, pass string) (StJobReport, error) {
+	job, err := LoadJob(path)
+	if err != nil {
+		return StJobReport{}, err
+	}
+	return job.Run(ctx, pass)
+}
+
+/*JobWorker : crea un utl.Job que ejecuta un archivo de job en cada ciclo del worker,
+el archivo se vuelve a leer en cada ejecucion*/
+func JobWorker(path, pass string) utl.Job {
+	return func(valid chan bool, errs chan error) {
+		_, err := RunJobFile(context.Background(), path, pass)
+		if err != nil {
+			errs <- err
+			return
+		}
+		valid <- true
+	}
+}
+
+/*LoadJobsWorker : lee la configuracion de un MasterWorker y crea los utl.Job de los workers que tienen
+un archivo de job, el resultado se envia a utl.NewMasterWorker*/
+func LoadJobsWorker(config, pass string) (map[string]utl.Job, error) {
+	var cfg utl.ConfigWorker
+	if !utl.FileExt(config, "JSON") {
+		return nil, fmt.Errorf("the config json file does not exist")
+	}
+	data, err := os.ReadFile(config)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, &cfg)
+	if err != nil {
+		return nil, err
+	}
+	jobs := make(map[string]utl.Job)
+	for _, v := range cfg.Workers {
+		if !utl.IsNilStr(v.Job) {
+			continue
+		}
+		_, err := LoadJob(v.Job)
+		if err != nil {
+			return nil, fmt.Errorf("worker %s: %s", v.Key, err.Error())
+		}
+		jobs[v.Key] = JobWorker(v.Job, pass)
+	}
+	return jobs, nil
+}
+
+/*NewJobMaster : crea un MasterWorker con los jobs declarados en su archivo de configuracion*/
+func NewJobMaster(config, pass string, indload bool) (utl.MasterWorker, error) {
+	jobs, err := LoadJobsWorker(config, pass)
+	if err != nil {
+		return utl.MasterWorker{}, err
+	}
+	return utl.NewMasterWorker(jobs, config, indload)
+}
+
+/*Valid : valida los campos obligatorios del job*/
+func (p *StJob) Valid() error {
+	if !utl.IsNilStr(p.Name) {
+		return fmt.Errorf("the job does not have a name")
+	}
+	if !utl.IsNilStr(p.In) || !utl.IsNilStr(p.Out) {
+		return fmt.Errorf("the job %s does not have the in and out connections", p.Name)
+	}
+	if len(p.Extractors) <= 0 {
+		return fmt.Errorf("the job %s does not have extractors", p.Name)
+	}
+	mode := p.mode()
+	if mode != JOBPROCESS && mode != JOBPARALLEL {
+		return fmt.Errorf("the job mode %s is invalid", p.Mode)
+	}
+	return nil
+}
+
+/*Merge : crea el StMerge del job cargando las conexiones, si pass esta vacio se lee de PassEnv*/
+func (p *StJob) Merge(pass string) (StMerge, error) {
+	var merge StMerge
+	err := p.Valid()
+	if err != nil {
+		return merge, err
+	}
+	if !utl.IsNilStr(pass) {
+		pass = os.Getenv(utl.ReturnIf(utl.IsNilStr(p.PassEnv), p.PassEnv, DBXPASS).(string))
+	}
+	cnxIn, err := jobConect(p.In, pass)
+	if err != nil {
+		return merge, err
+	}
+	cnxOut, err := jobConect(p.Out, pass)
+	if err != nil {
+		return merge, err
+	}
+	merge = StMerge{
+		CnxIn:     cnxIn,
+		CnxOut:    cnxOut,
+		ItemsExt:  p.Extractors,
+		InDelIn:   len(p.DelIn) > 0,
+		InDelOut:  len(p.DelOut) > 0,
+		DelsqlIn:  jobQueries(p.DelIn),
+		DelsqlOut: jobQueries(p.DelOut),
+		Batch:     p.Batch,
+		DryRun:    p.DryRun,
+		Workers:   p.Workers,
+		Chunk:     p.Chunk,
+	}
+	if utl.IsNilStr(p.State) {
+		merge.State = NewStateFile(p.State)
+	}
+	return merge, nil
+}
+
+/*Run : ejecuta el job con su modo de carga y escribe el reporte si tiene directorio Report,
+el reporte se regresa aun cuando el job falla*/
+func (p *StJob) Run(ctx context.Context, pass string) (StJobReport, error) {
+	report := StJobReport{
+		Job:    p.Name,
+		Mode:   p.mode(),
+		Status: JOBOK,
+		Start:  time.Now(),
+	}
+	err := p.run(ctx, pass, &report)
+	report.End = time.Now()
+	report.Elapsed = report.End.Sub(report.Start)
+	if err != nil {
+		report.Status = JOBERROR
+		report.Error = err.Error()
+	}
+	if utl.IsNilStr(p.Report) {
+		errSave := report.Save(p.Report)
+		if err == nil {
+			err = errSave
+		}
+	}
+	return report, err
+}
+
+/*Save : escribe el reporte en el directorio dir con el nombre <job>_<fecha>.json*/
+func (p *StJobReport) Save(dir string) error {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s_%s%s", p.Job, p.Start.Format("20060102150405"), utl.EXT["JSON"])
+	return os.WriteFile(filepath.Join(dir, name), data, 0666)
+}
+
+/*run : ejecuta el merge del job llenando las tablas y diferencias del reporte*/
+func (p *StJob) run(ctx context.Context, pass string, report *StJobReport) error {
+	merge, err := p.Merge(pass)
+	if err != nil {
+		return err
+	}
+	merge.Progress = func(item StProgress) {
+		if item.Done {
+			report.Tables = append(report.Tables, item)
+		}
+	}
+	if p.mode() == JOBPARALLEL {
+		err = merge.ProcessParallel(ctx)
+	} else {
+		err = merge.Process()
+	}
+	report.Diffs = merge.Reports
+	return err
+}
+
+/*mode : modo de carga del job en mayusculas*/
+func (p *StJob) mode() string {
+	mode := strings.ToUpper(strings.TrimSpace(p.Mode))
+	return utl.ReturnIf(utl.IsNilStr(mode), mode, JOBPROCESS).(string)
+}
+
+/*jobConect : carga una conexion segun la extension del archivo*/
+func jobConect(path, pass string) (StConect, error) {
+	var (
+		cnx StConect
+		err error
+	)
+	switch {
+	case utl.FileExt(path, "DBX"):
+		err = cnx.ConfigDBX(path, pass)
+	case utl.FileExt(path, "JSON"):
+		err = cnx.ConfigJSON(path)
+	case utl.FileExt(path, "INI"):
+		err = cnx.ConfigINI(path)
+	default:
+		err = fmt.Errorf("the connection file %s does not exist", path)
+	}
+	return cnx, err
+}
+
+/*jobQueries : convierte los scripts del job en StQuery*/
+func jobQueries(sqls []string) []StQuery {
+	var queries []StQuery
+	for _, sql := range sqls {
+		queries = append(queries, StQuery{Querie: sql})
+	}
+	return queries
+}
+
+/*readJobJSON : lee un job de un archivo json*/
+func readJobJSON(path string) (StJob, error) {
+	var job StJob
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return job, err
+	}
+	err = json.Unmarshal(data, &job)
+	return job, err
+}
+
+/*readJobIni : lee un job de un archivo ini con las secciones job, delin, delout y ext.<nombre>*/
+func readJobIni(path string) (StJob, error) {
+	var job StJob
+	cfg, err := ini.LoadSources(ini.LoadOptions{IgnoreInlineComment: true}, path)
+	if err != nil {
+		return job, err
+	}
+	err = cfg.Section("job").MapTo(&job)
+	if err != nil {
+		return job, err
+	}
+	for _, key := range cfg.Section("delin").Keys() {
+		job.DelIn = append(job.DelIn, key.String())
+	}
+	for _, key := range cfg.Section("delout").Keys() {
+		job.DelOut = append(job.DelOut, key.String())
+	}
+	for _, sec := range cfg.Sections() {
+		if !strings.HasPrefix(sec.Name(), "ext.") {
+			continue
+		}
+		var ext stJobExt
+		err = sec.MapTo(&ext)
+		if err != nil {
+			return job, err
+		}
+		for i := range ext.Index {
+			ext.Index[i] = strings.TrimSpace(ext.Index[i])
+		}
+		job.Extractors = append(job.Extractors, StExt{
+			SQLIn:        StQuery{Querie: ext.SQLIn},
+			TableNameOut: ext.Table,
+			Index:        ext.Index,
+			Watermark:    ext.Watermark,
+			Diff:         ext.Diff,
+		})
+	}
+	return job, nil
+}
@@ -0,0 +1,192 @@
+package database
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	utl "github.com/rafael180496/core-util/utility"
+)
+
+type (
+	/*sqlFile : archivo .sql leido para cargar queries nombrados*/
+	sqlFile struct {
+		path string
+		data []byte
+	}
+)
+
+var (
+	/*nameFor : formato de la anotacion de los queries nombrados -- name: findClient*/
+	nameFor = regexp.MustCompile(`^\s*--\s*name:\s*([A-Za-z0-9_\-\.]+)\s*$`)
+)
+
+/*
+LoadQueries : carga en Queries los queries nombrados de archivos .sql o directorios,
+cada query inicia con la anotacion -- name: findClient y termina en la siguiente anotacion.
+Si existe una variante del archivo con el tipo de base de datos (clients.ora.sql) se usa en lugar
+del archivo base (clients.sql) cuando Conexion.TP corresponde.
+*/
+func (p *StConect) LoadQueries(paths ...string) error {
+	var files []sqlFile
+	for _, item := range paths {
+		err := filepath.WalkDir(item, func(name string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() || filepath.Ext(name) != utl.EXT["SQL"] {
+				return nil
+			}
+			data, err := os.ReadFile(name)
+			if err != nil {
+				return err
+			}
+			files = append(files, sqlFile{path: filepath.ToSlash(name), data: data})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return p.loadQueries(files)
+}
+
+/*LoadQueriesFS : igual que LoadQueries pero lee los archivos de un fs.FS (embed.FS)*/
+func (p *StConect) LoadQueriesFS(fsys fs.FS, paths ...string) error {
+	var files []sqlFile
+	for _, item := range paths {
+		err := fs.WalkDir(fsys, item, func(name string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() || path.Ext(name) != utl.EXT["SQL"] {
+				return nil
+			}
+			data, err := fs.ReadFile(fsys, name)
+			if err != nil {
+				return err
+			}
+			files = append(files, sqlFile{path: name, data: data})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return p.loadQueries(files)
+}
+
+/*loadQueries : selecciona las variantes por tipo de base de datos y agrega los queries sin duplicados*/
+func (p *StConect) loadQueries(files []sqlFile) error {
+	queries := make(map[string]string)
+	for _, file := range selectSQLFiles(files, p.Conexion.TP) {
+		items, err := parseQueries(file)
+		if err != nil {
+			return err
+		}
+		for name, sql := range items {
+			if _, ok := queries[name]; ok {
+				return fmt.Errorf("duplicate query %s in %s", name, file.path)
+			}
+			if _, ok := p.Queries[name]; ok {
+				return fmt.Errorf("duplicate query %s in %s", name, file.path)
+			}
+			queries[name] = sql
+		}
+	}
+	if p.Queries == nil {
+		p.Queries = make(map[string]string)
+	}
+	for name, sql := range queries {
+		p.Queries[name] = sql
+	}
+	return nil
+}
+
+/*selectSQLFiles : deja un archivo por nombre base prefiriendo la variante del tipo de base de datos*/
+func selectSQLFiles(files []sqlFile, tp string) []sqlFile {
+	var (
+		result []sqlFile
+		keys   []string
+	)
+	base := make(map[string]sqlFile)
+	variant := make(map[string]sqlFile)
+	seen := make(map[string]bool)
+	for _, file := range files {
+		name := strings.TrimSuffix(file.path, utl.EXT["SQL"])
+		ext := strings.TrimPrefix(path.Ext(name), ".")
+		key := strings.TrimSuffix(name, "."+ext)
+		if ext == "" || !ValidPrefix(strings.ToUpper(ext)) {
+			key = name
+			ext = ""
+		}
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+		switch {
+		case ext == "":
+			base[key] = file
+		case strings.ToUpper(ext) == tp:
+			variant[key] = file
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if file, ok := variant[key]; ok {
+			result = append(result, file)
+		} else if file, ok := base[key]; ok {
+			result = append(result, file)
+		}
+	}
+	return result
+}
+
+/*parseQueries : lee las anotaciones -- name: de un archivo .sql*/
+func parseQueries(file sqlFile) (map[string]string, error) {
+	var (
+		name  string
+		lines []string
+	)
+	result := make(map[string]string)
+	add := func() error {
+		if name == "" {
+			return nil
+		}
+		sql := strings.TrimSuffix(strings.TrimSpace(strings.Join(lines, "\n")), ";")
+		if !utl.IsNilStr(sql) {
+			return fmt.Errorf("the query %s in %s is empty", name, file.path)
+		}
+		if _, ok := result[name]; ok {
+			return fmt.Errorf("duplicate query %s in %s", name, file.path)
+		}
+		result[name] = sql
+		return nil
+	}
+	text := strings.ReplaceAll(string(file.data), "\r", "")
+	for _, line := range strings.Split(text, "\n") {
+		match := nameFor.FindStringSubmatch(line)
+		if match == nil {
+			if name != "" {
+				lines = append(lines, line)
+			}
+			continue
+		}
+		err := add()
+		if err != nil {
+			return result, err
+		}
+		name = match[1]
+		lines = nil
+	}
+	err := add()
+	if err != nil {
+		return result, err
+	}
+	return result, nil
+}
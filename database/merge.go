@@ -2,16 +2,36 @@ package database
 
 import (
 	"fmt"
+	"strings"
+	"time"
+
+	utl "github.com/rafael180496/core-util/utility"
 )
 
 type (
-	/*StExt : extractores para la extructura merge y datatable*/
+	/*StExt : extractores para la extructura merge y datatable
+	Watermark : columna de marca (fecha o id creciente) que activa el modo incremental,
+	solo se extraen las filas mayores a la ultima marca y se hace upsert en la salida por Index
+	Diff : compara la entrada con la tabla de salida por Index y solo aplica los cambios necesarios
+	Steps : pasos de transformacion declarados en configuracion que se aplican antes de cargar la salida
+	Transforms : transformaciones en go que se aplican despues de Steps*/
 	StExt struct {
 		SQLIn        StQuery
 		TableNameOut string
 		Index        []string
+		Watermark    string
+		Diff         bool
+		Steps        []StStep
+		Transforms   []StTransform `json:"-"`
 	}
-	/*StMerge :  estructura para crear merge para servicio*/
+	/*StMerge :  estructura para crear merge para servicio
+	Batch : cantidad de filas por insert al cargar la salida (0 inserta fila por fila)
+	State : almacenamiento de las marcas de los extractores incrementales por TableNameOut
+	DryRun : los extractores Diff solo generan el reporte sin ejecutar los cambios
+	Reports : reportes de los extractores Diff del ultimo Process
+	Workers : extractores que se ejecutan al mismo tiempo en ProcessParallel (0 usa todos los cpu)
+	Chunk : filas por bloque que se escriben en la salida en ProcessParallel (0 usa CHUNKROWS)
+	Progress : funcion que recibe el avance de cada extractor en Process y ProcessParallel*/
 	StMerge struct {
 		CnxIn     StConect
 		CnxOut    StConect
@@ -20,15 +40,23 @@ type (
 		InDelOut  bool
 		DelsqlIn  []StQuery
 		DelsqlOut []StQuery
+		Batch     int
+		State     StSyncState
+		DryRun    bool
+		Reports   []StDiffReport
+		Workers   int
+		Chunk     int
+		Progress  func(StProgress)
 		//AccMerge : procesa la accion para hacer el merge de la base de datos
 		AccMerge func(CnxIn, CnxOut StConect) error
 	}
 )
 
-/*LoadDataIn : carga los datos de la base de datos de entrada*/
+/*LoadDataIn : carga los datos de la base de datos de entrada, los extractores incrementales se cargan en SyncExt
+y los extractores Diff en DiffExt*/
 func (p *StMerge) LoadDataIn() ([]DataTable, error) {
 	cnx := p.CnxIn
-	defer cnx.Close()
+	defer cnx.release()
 	var (
 		err    error
 		result []DataTable
@@ -43,10 +71,17 @@ func (p *StMerge) LoadDataIn() ([]DataTable, error) {
 		return result, fmt.Errorf("los extractores estan vacio")
 	}
 	for _, v := range p.ItemsExt {
+		if utl.IsNilStr(v.Watermark) || v.Diff {
+			continue
+		}
 		data, err := cnx.QueryMap(v.SQLIn, 0, true, false)
 		if err != nil {
 			return result, err
 		}
+		data, err = v.TransformRows(data)
+		if err != nil {
+			return result, err
+		}
 		datatable := NewDataTable(v.TableNameOut,
 			data, v.Index)
 		result = append(result, datatable)
@@ -55,6 +90,97 @@ func (p *StMerge) LoadDataIn() ([]DataTable, error) {
 	return result, err
 }
 
+/*ValidExt : valida con el catalogo de CnxOut que las tablas de salida y las columnas de los indices existan,
+si un extractor no tiene indices se cargan las columnas de la llave primaria de la tabla*/
+func (p *StMerge) ValidExt() error {
+	cnx := p.CnxOut
+	defer cnx.release()
+	for i, v := range p.ItemsExt {
+		columns, err := cnx.DescribeTable(v.TableNameOut, true)
+		if err != nil {
+			return err
+		}
+		var (
+			names []string
+			keys  []string
+		)
+		for _, col := range columns {
+			names = append(names, col.Name)
+			if col.Key {
+				keys = append(keys, col.Name)
+			}
+		}
+		for _, index := range v.Index {
+			if !inCols(index, names) {
+				return fmt.Errorf("the column %s does not exist in the table %s", index, v.TableNameOut)
+			}
+		}
+		if len(v.Index) <= 0 {
+			p.ItemsExt[i].Index = keys
+		}
+	}
+	return nil
+}
+
+/*SyncExt : sincroniza un extractor incremental extrayendo las filas mayores a la ultima marca guardada,
+hace upsert en la salida y solo despues de confirmar la transaccion guarda la nueva marca,
+regresa la cantidad de filas sincronizadas*/
+func (p *StMerge) SyncExt(v StExt) (int, error) {
+	if !utl.IsNilStr(v.Watermark) {
+		return 0, fmt.Errorf("the extractor %s does not have a watermark column", v.TableNameOut)
+	}
+	if p.State == nil {
+		return 0, fmt.Errorf("the merge does not have a watermark state")
+	}
+	if len(v.Index) <= 0 {
+		return 0, fmt.Errorf("they have no loaded index")
+	}
+	mark, ok, err := p.State.Load(v.TableNameOut)
+	if err != nil {
+		return 0, err
+	}
+	cnxIn := p.CnxIn
+	defer cnxIn.release()
+	rows, err := cnxIn.QueryMap(markQuery(v.SQLIn, v.Watermark, mark, ok), 0, true, false)
+	if err != nil {
+		return 0, err
+	}
+	if len(rows) <= 0 {
+		return 0, nil
+	}
+	column := strings.ToUpper(v.Watermark)
+	var last interface{}
+	for _, item := range rows {
+		row := item.UpperKey()
+		vl, ok := row[column]
+		if !ok || vl == nil {
+			continue
+		}
+		if last == nil || compareMark(vl, last) > 0 {
+			last = vl
+		}
+	}
+	if last == nil {
+		return 0, fmt.Errorf("the watermark column %s has no values", v.Watermark)
+	}
+	rows, err = v.TransformRows(rows)
+	if err != nil {
+		return 0, err
+	}
+	data := NewDataTable(v.TableNameOut, rows, nil)
+	err = data.AddIndexs(v.Index...)
+	if err != nil {
+		return 0, err
+	}
+	cnxOut := p.CnxOut
+	defer cnxOut.release()
+	err = cnxOut.ExecDatatable(data, UPSERT, true)
+	if err != nil {
+		return 0, err
+	}
+	return data.LenRows(), p.State.Save(v.TableNameOut, newWatermark(last))
+}
+
 /*Process :  proceso de merge en los etl*/
 func (p *StMerge) Process() error {
 	data, err := p.LoadDataIn()
@@ -69,16 +195,59 @@ func (p *StMerge) Process() error {
 		}
 	}
 	for _, v := range data {
+		start := time.Now()
+		v.SetBatch(p.Batch)
 		err = cnx.ExecDatatable(v, INSERT, true)
 		if err != nil {
 			return err
 		}
+		p.progress(StProgress{
+			Table:   v.GetTable(),
+			Read:    v.LenRows(),
+			Written: v.LenRows(),
+			Elapsed: time.Since(start),
+			Done:    true,
+		})
 	}
-	cnx.Close()
-	err = p.AccMerge(p.CnxIn, p.CnxOut)
-	if err != nil {
-		return err
+	cnx.release()
+	return p.processSync()
+}
+
+/*processSync : procesa los extractores incrementales y Diff y ejecuta AccMerge*/
+func (p *StMerge) processSync() error {
+	p.Reports = nil
+	for _, v := range p.ItemsExt {
+		switch {
+		case v.Diff:
+			report, err := p.DiffExt(v, p.DryRun)
+			if err != nil {
+				return err
+			}
+			p.Reports = append(p.Reports, report)
+		case utl.IsNilStr(v.Watermark):
+			start := time.Now()
+			rows, err := p.SyncExt(v)
+			if err != nil {
+				return err
+			}
+			p.progress(StProgress{
+				Table:   v.TableNameOut,
+				Read:    rows,
+				Written: rows,
+				Elapsed: time.Since(start),
+				Done:    true,
+			})
+		}
+	}
+	if p.AccMerge == nil {
+		return nil
 	}
+	return p.AccMerge(p.CnxIn, p.CnxOut)
+}
 
-	return nil
+/*progress : envia el avance de un extractor si el merge tiene la funcion Progress*/
+func (p *StMerge) progress(item StProgress) {
+	if p.Progress != nil {
+		p.Progress(item)
+	}
 }
@@ -1,9 +1,11 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/jmoiron/sqlx"
 	utl "github.com/rafael180496/core-util/utility"
@@ -11,20 +13,29 @@ import (
 
 /*QueryNative :  ejecuta la funcion nativa del paquete sql*/
 func (p *StConect) QueryNative(sql string, indConect bool, args ...interface{}) (*sql.Rows, error) {
-	if !utl.IsNilStr(sql) {
+	return p.QueryNativeCtx(context.Background(), sql, indConect, args...)
+}
+
+/*QueryNativeCtx :  ejecuta la funcion nativa del paquete sql con un contexto de cancelacion*/
+func (p *StConect) QueryNativeCtx(ctx context.Context, querie string, indConect bool, args ...interface{}) (*sql.Rows, error) {
+	if !utl.IsNilStr(querie) {
 		return nil, utl.StrErr("el Query esta vacio")
 	}
-	err := p.Con()
+	err := p.ConCtx(ctx)
 	if err != nil {
 		return nil, err
 	}
-	rows, err := p.DBGO.Query(sql, args...)
+	var rows *sql.Rows
+	err = runStmt(ctx, p.Hooks, newStmt(HOOKQUERY, querie, args, nil), func(querie string, args []interface{}) (int64, error) {
+		rows, err = p.DBGO.QueryContext(ctx, querie, args...)
+		return -1, err
+	})
 	if err != nil {
-		p.Close()
+		p.release()
 		return rows, err
 	}
 	if !indConect {
-		p.Close()
+		p.release()
 	}
 	return rows, nil
 }
@@ -32,7 +43,12 @@ func (p *StConect) QueryNative(sql string, indConect bool, args ...interface{})
 /*QueryOne : Ejecuta un querie en la base de datos y devuelve un map dinamico pero solo envia una fila y no un arreglo
  */
 func (p *StConect) QueryOne(query StQuery, indConect bool) (StData, error) {
-	result, err := p.queryGeneric(query, 1, indConect, true)
+	return p.QueryOneCtx(context.Background(), query, indConect)
+}
+
+/*QueryOneCtx : igual que QueryOne pero con un contexto de cancelacion*/
+func (p *StConect) QueryOneCtx(ctx context.Context, query StQuery, indConect bool) (StData, error) {
+	result, err := p.queryGeneric(ctx, query, 1, indConect, true)
 	if err != nil {
 		return nil, err
 	}
@@ -51,10 +67,15 @@ func (p *StConect) QueryOne(query StQuery, indConect bool) (StData, error) {
   map[COD_CLI:50364481 NIS_RAD:5355046 SEC_NIS:1]
 */
 func (p *StConect) Query(query StQuery, cantrow int, indConect bool) ([]StData, error) {
+	return p.QueryCtx(context.Background(), query, cantrow, indConect)
+}
+
+/*QueryCtx : igual que Query pero con un contexto de cancelacion*/
+func (p *StConect) QueryCtx(ctx context.Context, query StQuery, cantrow int, indConect bool) ([]StData, error) {
 	if cantrow <= 0 {
 		return nil, fmt.Errorf("row quantity is zero")
 	}
-	return p.queryGeneric(query, cantrow, indConect, true)
+	return p.queryGeneric(ctx, query, cantrow, indConect, true)
 }
 
 /*QueryMap : Ejecuta un querie en la base de datos y
@@ -64,7 +85,12 @@ func (p *StConect) Query(query StQuery, cantrow int, indConect bool) ([]StData,
 	indLimit = true limite de fila si esta en false desactiva esta opcion
 */
 func (p *StConect) QueryMap(query StQuery, cantrow int, indConect, indLimit bool) ([]StData, error) {
-	result, err := p.queryGeneric(query, cantrow, indConect, indLimit)
+	return p.QueryMapCtx(context.Background(), query, cantrow, indConect, indLimit)
+}
+
+/*QueryMapCtx : igual que QueryMap pero con un contexto de cancelacion*/
+func (p *StConect) QueryMapCtx(ctx context.Context, query StQuery, cantrow int, indConect, indLimit bool) ([]StData, error) {
+	result, err := p.queryGeneric(ctx, query, cantrow, indConect, indLimit)
 	if err != nil {
 		return nil, err
 	}
@@ -78,7 +104,12 @@ func (p *StConect) QueryMap(query StQuery, cantrow int, indConect, indLimit bool
 	indLimit = true limite de fila si esta en false desactiva esta opcion
 */
 func (p *StConect) QueryJSON(query StQuery, cantrow int, indConect, indLimit bool) ([]byte, error) {
-	result, err := p.queryGeneric(query, cantrow, indConect, indLimit)
+	return p.QueryJSONCtx(context.Background(), query, cantrow, indConect, indLimit)
+}
+
+/*QueryJSONCtx : igual que QueryJSON pero con un contexto de cancelacion*/
+func (p *StConect) QueryJSONCtx(ctx context.Context, query StQuery, cantrow int, indConect, indLimit bool) ([]byte, error) {
+	result, err := p.queryGeneric(ctx, query, cantrow, indConect, indLimit)
 	if err != nil {
 		return nil, err
 	}
@@ -95,26 +126,45 @@ func (p *StConect) QueryJSON(query StQuery, cantrow int, indConect, indLimit boo
 	indConect = true deja la conexion abierta
 */
 func (p *StConect) QueryStruct(datadest interface{}, query StQuery, indConect bool) error {
-	var (
-		err     error
-		args    []interface{}
-		sqltemp string
-	)
-	err = p.Con()
-	if err != nil {
-		return err
+	return p.QueryStructCtx(context.Background(), datadest, query, indConect)
+}
+
+/*QueryStructCtx : igual que QueryStruct pero con un contexto de cancelacion*/
+func (p *StConect) QueryStructCtx(ctx context.Context, datadest interface{}, query StQuery, indConect bool) error {
+	size := lenRows(datadest)
+	if replica := p.replica(ctx, query); replica != nil {
+		err := replica.QueryStructCtx(ctx, datadest, query, indConect)
+		if err == nil || !replica.Transient(err) {
+			return err
+		}
+		p.replicaDown(replica)
 	}
-	sqltemp, args, err = p.NamedIn(query)
-	if err != nil {
+	err := p.retry(ctx, func() error {
+		resetRows(datadest, size)
+		err := p.connect(ctx)
+		if err != nil {
+			return err
+		}
+		sqltemp, args, err := p.NamedIn(query)
+		if err != nil {
+			return err
+		}
+		qctx, cancel := query.withTimeout(ctx)
+		defer cancel()
+		err = runStmt(ctx, p.Hooks, newStmt(HOOKQUERY, sqltemp, args, query.Args), func(querie string, args []interface{}) (int64, error) {
+			err := p.DBGO.SelectContext(qctx, datadest, querie, args...)
+			return lenRows(datadest), err
+		})
+		if err != nil {
+			p.release()
+		}
 		return err
-	}
-	err = p.DBGO.Select(datadest, sqltemp, args...)
+	})
 	if err != nil {
-		p.Close()
 		return err
 	}
 	if !indConect {
-		p.Close()
+		p.release()
 	}
 	return nil
 }
@@ -124,13 +174,23 @@ func (p *StConect) QueryStruct(datadest interface{}, query StQuery, indConect bo
 	indConect = true deja la conexion abierta
 */
 func (p *StConect) QueryRows(query StQuery, indConect bool) (*sqlx.Rows, error) {
+	return p.QueryRowsCtx(context.Background(), query, indConect)
+}
+
+/*QueryRowsCtx : igual que QueryRows pero con un contexto de cancelacion,
+el Timeout del StQuery no se aplica porque las filas se leen despues de regresar
+la funcion, el limite de tiempo lo controla el contexto enviado*/
+func (p *StConect) QueryRowsCtx(ctx context.Context, query StQuery, indConect bool) (*sqlx.Rows, error) {
+	if replica := p.replica(ctx, query); replica != nil {
+		return replica.QueryRowsCtx(ctx, query, indConect)
+	}
 	var (
 		err     error
 		filas   *sqlx.Rows
 		sqltemp string
 		args    []interface{}
 	)
-	err = p.Con()
+	err = p.ConCtx(ctx)
 	if err != nil {
 		return filas, err
 	}
@@ -138,13 +198,97 @@ func (p *StConect) QueryRows(query StQuery, indConect bool) (*sqlx.Rows, error)
 	if err != nil {
 		return filas, err
 	}
-	filas, err = p.DBGO.Queryx(sqltemp, args...)
+	err = runStmt(ctx, p.Hooks, newStmt(HOOKQUERY, sqltemp, args, query.Args), func(querie string, args []interface{}) (int64, error) {
+		filas, err = p.DBGO.QueryxContext(ctx, querie, args...)
+		return -1, err
+	})
 	if err != nil {
-		p.Close()
+		p.release()
 		return filas, err
 	}
 	if !indConect {
-		p.Close()
+		p.release()
 	}
 	return filas, nil
 }
+
+/*QueryEach : Ejecuta un query en la base de datos enviando cada fila a la funcion sin cargar
+todo el resultado en memoria, si la funcion regresa un error se detiene la lectura y se regresa el error
+	indConect = true deja la conexion abierta aunque la lectura termine con error
+*/
+func (p *StConect) QueryEach(query StQuery, indConect bool, fn func(StData) error) error {
+	return p.QueryEachCtx(context.Background(), query, indConect, fn)
+}
+
+/*QueryEachCtx : igual que QueryEach pero con un contexto de cancelacion*/
+func (p *StConect) QueryEachCtx(ctx context.Context, query StQuery, indConect bool, fn func(StData) error) error {
+	if replica := p.replica(ctx, query); replica != nil {
+		return replica.QueryEachCtx(ctx, query, indConect, fn)
+	}
+	err := p.ConCtx(ctx)
+	if err != nil {
+		return err
+	}
+	err = eachExt(ctx, p.DBGO, p.Hooks, query, fn)
+	if !indConect {
+		p.release()
+	}
+	return err
+}
+
+/*QueryPage : Ejecuta un query en la base de datos regresando solo la pagina indicada,
+el limite de filas se agrega al sql segun el dialecto de la conexion (la primera pagina es 1)
+	indCount = true ejecuta un COUNT(*) del query para enviar el total de filas y paginas
+	indConect = true deja la conexion abierta
+*/
+func (p *StConect) QueryPage(query StQuery, page, size int, indCount, indConect bool) (StPage, error) {
+	return p.QueryPageCtx(context.Background(), query, page, size, indCount, indConect)
+}
+
+/*QueryPageCtx : igual que QueryPage pero con un contexto de cancelacion*/
+func (p *StConect) QueryPageCtx(ctx context.Context, query StQuery, page, size int, indCount, indConect bool) (StPage, error) {
+	result := StPage{
+		Page: page,
+		Size: size,
+	}
+	if page <= 0 || size <= 0 {
+		return result, fmt.Errorf("the page and size must be greater than zero")
+	}
+	d, err := p.Dialect()
+	if err != nil {
+		return result, err
+	}
+	sqltemp := strings.TrimSuffix(strings.TrimSpace(query.Querie), ";")
+	if indCount {
+		count, err := p.queryGeneric(ctx, StQuery{
+			Querie:  countQuery(sqltemp),
+			Args:    query.Args,
+			Timeout: query.Timeout,
+			Primary: query.Primary,
+		}, 1, true, true)
+		if err != nil {
+			return result, err
+		}
+		if len(count) > 0 {
+			row := count[0].UpperKey()
+			result.Total, _ = row.ToInt64("TOTAL")
+		}
+		result.Pages = (result.Total + int64(size) - 1) / int64(size)
+	}
+	/*se pide una fila extra para saber si existe una pagina siguiente*/
+	data, err := p.queryGeneric(ctx, StQuery{
+		Querie:  d.Limit(sqltemp, size+1, (page-1)*size),
+		Args:    query.Args,
+		Timeout: query.Timeout,
+		Primary: query.Primary,
+	}, 0, indConect, false)
+	if err != nil {
+		return result, err
+	}
+	if len(data) > size {
+		result.Next = true
+		data = data[:size]
+	}
+	result.Data = data
+	return result, nil
+}
@@ -0,0 +1,277 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	utl "github.com/rafael180496/core-util/utility"
+	"gopkg.in/ini.v1"
+)
+
+type (
+	/*ConnRegistry : conexiones con nombre que se comparten en la aplicacion, cada conexion se abre
+	la primera vez que se pide con Get y se cierran todas con CloseAll, el registro es dueño del pool
+	por lo que Close y los errores de las consultas no cierran el *StConect compartido,
+	locks evita abrir dos veces el mismo nombre sin bloquear el registro mientras se conecta*/
+	ConnRegistry struct {
+		mu    sync.Mutex
+		cads  map[string]StCadConect
+		cnxs  map[string]*StConect
+		locks map[string]*sync.Mutex
+	}
+)
+
+/*NewConnRegistry : crea un registro de conexiones vacio*/
+func NewConnRegistry() *ConnRegistry {
+	return &ConnRegistry{
+		cads:  make(map[string]StCadConect),
+		cnxs:  make(map[string]*StConect),
+		locks: make(map[string]*sync.Mutex),
+	}
+}
+
+/*
+LoadRegistry : carga un registro de un archivo .ini, .json o de un directorio de archivos .dbx,
+pass solo se usa para desencriptar los .dbx
+
+Ejemplo ini, la seccion [database] se registra con el nombre DEFAULTCNX:
+
+[database.sales]
+tp = POST
+...
+
+[database.erp]
+tp = ORA
+...
+
+Ejemplo json:
+
+{
+	"sales": {"tp":"POST", ...},
+	"erp": {"tp":"ORA", ...}
+}
+
+En un directorio cada archivo .dbx se registra con el nombre del archivo sin extension.
+*/
+func LoadRegistry(path, pass string) (*ConnRegistry, error) {
+	info, err := os.Stat(path)
+	switch {
+	case err == nil && info.IsDir():
+		return LoadRegistryDBX(path, pass)
+	case utl.FileExt(path, "INI"):
+		return LoadRegistryINI(path)
+	case utl.FileExt(path, "JSON"):
+		return LoadRegistryJSON(path)
+	default:
+		return nil, fmt.Errorf("the registry file %s does not exist", path)
+	}
+}
+
+/*LoadRegistryINI : carga las conexiones de las secciones [database.<nombre>] de un .ini*/
+func LoadRegistryINI(path string) (*ConnRegistry, error) {
+	if !utl.FileExt(path, "INI") {
+		return nil, fmt.Errorf("the config ini file does not exist")
+	}
+	cfg, err := ini.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	registry := NewConnRegistry()
+	for _, sec := range cfg.Sections() {
+		name := sec.Name()
+		switch {
+		case name == "database":
+			name = DEFAULTCNX
+		case strings.HasPrefix(name, "database."):
+			name = strings.TrimPrefix(name, "database.")
+		default:
+			continue
+		}
+		var cad StCadConect
+		err = sec.MapTo(&cad)
+		if err != nil {
+			return nil, err
+		}
+		err = registry.Add(name, cad)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return registry, registry.valid()
+}
+
+/*LoadRegistryJSON : carga las conexiones de un .json con un objeto por nombre*/
+func LoadRegistryJSON(path string) (*ConnRegistry, error) {
+	if !utl.FileExt(path, "JSON") {
+		return nil, fmt.Errorf("the config json file does not exist")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cads map[string]StCadConect
+	err = json.Unmarshal(data, &cads)
+	if err != nil {
+		return nil, err
+	}
+	registry := NewConnRegistry()
+	for name, cad := range cads {
+		err = registry.Add(name, cad)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return registry, registry.valid()
+}
+
+/*LoadRegistryDBX : carga los archivos .dbx de un directorio con la misma llave*/
+func LoadRegistryDBX(dir, pass string) (*ConnRegistry, error) {
+	files, err := utl.ListDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	registry := NewConnRegistry()
+	for _, file := range files {
+		if file.IsDir() || !strings.EqualFold(filepath.Ext(file.Name()), utl.EXT["DBX"]) {
+			continue
+		}
+		var cnx StConect
+		err = cnx.ConfigDBX(filepath.Join(dir, file.Name()), pass)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", file.Name(), err.Error())
+		}
+		err = registry.Add(strings.TrimSuffix(file.Name(), filepath.Ext(file.Name())), cnx.Conexion)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return registry, registry.valid()
+}
+
+/*Add : registra o reemplaza una conexion por nombre, si ya estaba abierta se cierra*/
+func (p *ConnRegistry) Add(name string, cad StCadConect) error {
+	name = registryName(name)
+	if !utl.IsNilStr(name) {
+		return fmt.Errorf("the connection name is empty")
+	}
+	if !cad.ValidCad() {
+		return fmt.Errorf("the connection %s is invalid", name)
+	}
+	lock := p.lock(name)
+	lock.Lock()
+	defer lock.Unlock()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if cnx, ok := p.cnxs[name]; ok {
+		cnx.closePool()
+		delete(p.cnxs, name)
+	}
+	p.cads[name] = cad
+	return nil
+}
+
+/*Get : envia la conexion compartida del nombre abriendola la primera vez*/
+func (p *ConnRegistry) Get(name string) (*StConect, error) {
+	return p.GetCtx(context.Background(), name)
+}
+
+/*GetCtx : igual que Get pero con un contexto de cancelacion, la conexion se abre fuera del bloqueo
+del registro para no detener los Get de otros nombres*/
+func (p *ConnRegistry) GetCtx(ctx context.Context, name string) (*StConect, error) {
+	name = registryName(name)
+	cnx, cad, err := p.lookup(name)
+	if cnx != nil || err != nil {
+		return cnx, err
+	}
+	lock := p.lock(name)
+	lock.Lock()
+	defer lock.Unlock()
+	cnx, cad, err = p.lookup(name)
+	if cnx != nil || err != nil {
+		return cnx, err
+	}
+	cnx = &StConect{Conexion: cad, shared: true}
+	err = cnx.ConCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cnxs[name] = cnx
+	return cnx, nil
+}
+
+/*lookup : envia la conexion abierta del nombre o la cadena para abrirla*/
+func (p *ConnRegistry) lookup(name string) (*StConect, StCadConect, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if cnx, ok := p.cnxs[name]; ok {
+		return cnx, StCadConect{}, nil
+	}
+	cad, ok := p.cads[name]
+	if !ok {
+		return nil, cad, fmt.Errorf("the connection %s does not exist", name)
+	}
+	return nil, cad, nil
+}
+
+/*lock : envia el bloqueo del nombre para abrir o reemplazar su conexion*/
+func (p *ConnRegistry) lock(name string) *sync.Mutex {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	lock, ok := p.locks[name]
+	if !ok {
+		lock = &sync.Mutex{}
+		p.locks[name] = lock
+	}
+	return lock
+}
+
+/*Names : envia los nombres registrados en orden*/
+func (p *ConnRegistry) Names() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var names []string
+	for name := range p.cads {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+/*CloseAll : cierra todas las conexiones abiertas, se pueden volver a abrir con Get*/
+func (p *ConnRegistry) CloseAll() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var errs []string
+	for name, cnx := range p.cnxs {
+		err := cnx.closePool()
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", name, err.Error()))
+		}
+		delete(p.cnxs, name)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("error closing connections: %s", strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+/*valid : valida que el registro tenga conexiones*/
+func (p *ConnRegistry) valid() error {
+	if len(p.cads) <= 0 {
+		return fmt.Errorf("the registry does not have connections")
+	}
+	return nil
+}
+
+/*registryName : nombre de una conexion en minusculas*/
+func registryName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
@@ -0,0 +1,251 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	utl "github.com/rafael180496/core-util/utility"
+)
+
+type (
+	/*StReplicaStatus : estado de una replica de lectura
+	Healthy : la ultima revision de la replica fue correcta
+	Latency : tiempo del ultimo ping
+	Checked : fecha de la ultima revision*/
+	StReplicaStatus struct {
+		Host    string        `json:"host"`
+		Port    int           `json:"port"`
+		File    string        `json:"file"`
+		Healthy bool          `json:"healthy"`
+		Latency time.Duration `json:"latency"`
+		Checked time.Time     `json:"checked"`
+	}
+	/*stReplica : conexion de una replica con su estado, checking indica que la replica esta en revision
+	y done se cierra al terminar la revision*/
+	stReplica struct {
+		cnx      *StConect
+		status   StReplicaStatus
+		checking bool
+		done     chan struct{}
+	}
+	/*replicaSet : replicas de lectura compartidas entre las copias de un StConect*/
+	replicaSet struct {
+		mu    sync.Mutex
+		mode  string
+		items []*stReplica
+		next  int
+	}
+	/*primaryKey : llave del contexto que obliga a leer de la base principal*/
+	primaryKey struct{}
+)
+
+/*WithPrimary : crea un contexto que envia las consultas a la base principal aunque existan replicas*/
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+/*ReplicaStatus : envia el estado de las replicas de lectura de la conexion*/
+func (p *StConect) ReplicaStatus() []StReplicaStatus {
+	var result []StReplicaStatus
+	if p.replicas == nil {
+		return result
+	}
+	p.replicas.mu.Lock()
+	defer p.replicas.mu.Unlock()
+	for _, item := range p.replicas.items {
+		result = append(result, item.status)
+	}
+	return result
+}
+
+/*initReplicas : crea las replicas configuradas sin conectarlas, cada replica es un pool compartido
+que solo se cierra con el Close de la base principal*/
+func (p *StConect) initReplicas() {
+	if p.replicas != nil || len(p.Conexion.Replicas) <= 0 {
+		return
+	}
+	set := &replicaSet{
+		mode: strings.ToUpper(strings.TrimSpace(p.Conexion.ReplicaMode)),
+	}
+	for _, item := range p.Conexion.Replicas {
+		cad := replicaCad(p.Conexion, item)
+		set.items = append(set.items, &stReplica{
+			cnx: &StConect{Conexion: cad, Hooks: p.Hooks, shared: true},
+			status: StReplicaStatus{
+				Host: cad.Host,
+				Port: cad.Port,
+				File: cad.File,
+			},
+		})
+	}
+	p.replicas = set
+}
+
+/*CheckReplicas : revisa las replicas que no estan en revision y espera el resultado de todas las revisiones,
+sirve para conocer el estado de las replicas antes de las primeras consultas*/
+func (p *StConect) CheckReplicas() {
+	p.initReplicas()
+	if p.replicas == nil {
+		return
+	}
+	for _, done := range p.replicas.checkDue(true) {
+		<-done
+	}
+}
+
+/*replica : envia la replica de lectura para el query o nil si se debe usar la base principal,
+las replicas se revisan en segundo plano cada REPLICACHECK segundos y la consulta se envia
+segun el ultimo estado conocido sin esperar la revision, las replicas en revision no se usan*/
+func (p *StConect) replica(ctx context.Context, query StQuery) *StConect {
+	if query.Primary || ctx.Value(primaryKey{}) != nil {
+		return nil
+	}
+	p.initReplicas()
+	if p.replicas == nil {
+		return nil
+	}
+	set := p.replicas
+	set.checkDue(false)
+	set.mu.Lock()
+	defer set.mu.Unlock()
+	var healthy []*stReplica
+	for _, item := range set.items {
+		if item.status.Healthy && !item.checking {
+			healthy = append(healthy, item)
+		}
+	}
+	if len(healthy) <= 0 {
+		return nil
+	}
+	if set.mode == REPLICALATENCY {
+		best := healthy[0]
+		for _, item := range healthy[1:] {
+			if item.status.Latency < best.status.Latency {
+				best = item
+			}
+		}
+		return best.cnx
+	}
+	set.next = (set.next + 1) % len(healthy)
+	return healthy[set.next].cnx
+}
+
+/*replicaDown : marca una replica con error para no usarla hasta la siguiente revision*/
+func (p *StConect) replicaDown(cnx *StConect) {
+	set := p.replicas
+	set.mu.Lock()
+	defer set.mu.Unlock()
+	for _, item := range set.items {
+		if item.cnx == cnx {
+			item.status.Healthy = false
+			item.status.Checked = time.Now()
+		}
+	}
+}
+
+/*hookReplicas : copia los hooks de la conexion en las replicas*/
+func (p *StConect) hookReplicas() {
+	if p.replicas == nil {
+		return
+	}
+	p.replicas.mu.Lock()
+	defer p.replicas.mu.Unlock()
+	for _, item := range p.replicas.items {
+		item.cnx.Hooks = p.Hooks
+	}
+}
+
+/*closeReplicas : cierra las conexiones de las replicas, se vuelven a abrir en la siguiente revision*/
+func (p *StConect) closeReplicas() {
+	if p.replicas == nil {
+		return
+	}
+	p.replicas.mu.Lock()
+	defer p.replicas.mu.Unlock()
+	for _, item := range p.replicas.items {
+		if item.checking {
+			continue
+		}
+		item.cnx.closePool()
+		item.cnx.DBGO = nil
+		item.status.Healthy = false
+		item.status.Checked = time.Time{}
+	}
+}
+
+/*checkDue : inicia en segundo plano la revision de las replicas que les toca o de todas con force
+y envia los canales que se cierran al terminar las revisiones en curso*/
+func (p *replicaSet) checkDue(force bool) []chan struct{} {
+	var result []chan struct{}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, item := range p.items {
+		if !item.checking && (force || time.Since(item.status.Checked) >= REPLICACHECK*time.Second) {
+			item.checking = true
+			item.done = make(chan struct{})
+			go p.check(item)
+		}
+		if item.checking {
+			result = append(result, item.done)
+		}
+	}
+	return result
+}
+
+/*check : revisa la replica con un ping midiendo la latencia y guarda el resultado,
+solo la ejecuta la revision que marco la replica en revision*/
+func (p *replicaSet) check(item *stReplica) {
+	ctx, cancel := context.WithTimeout(context.Background(), REPLICACHECK*time.Second)
+	defer cancel()
+	start := time.Now()
+	err := item.cnx.connect(ctx)
+	latency := time.Since(start)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	item.status.Healthy = err == nil
+	item.status.Latency = latency
+	item.status.Checked = time.Now()
+	item.checking = false
+	close(item.done)
+}
+
+/*replicaCad : completa la cadena de la replica con los datos de la base principal que esten vacios*/
+func replicaCad(primary, replica StCadConect) StCadConect {
+	fill := func(vl *string, def string) {
+		if !utl.IsNilStr(*vl) {
+			*vl = def
+		}
+	}
+	fill(&replica.TP, primary.TP)
+	fill(&replica.User, primary.User)
+	fill(&replica.Pass, primary.Pass)
+	fill(&replica.Name, primary.Name)
+	fill(&replica.Host, primary.Host)
+	fill(&replica.Sslmode, primary.Sslmode)
+	fill(&replica.Sid, primary.Sid)
+	fill(&replica.Wallet, primary.Wallet)
+	if replica.Port <= 0 {
+		replica.Port = primary.Port
+	}
+	if replica.MaxOpen <= 0 {
+		replica.MaxOpen = primary.MaxOpen
+	}
+	if replica.MaxIdle <= 0 {
+		replica.MaxIdle = primary.MaxIdle
+	}
+	if replica.MaxLifetime <= 0 {
+		replica.MaxLifetime = primary.MaxLifetime
+	}
+	if replica.MaxIdleTime <= 0 {
+		replica.MaxIdleTime = primary.MaxIdleTime
+	}
+	if replica.Retries <= 0 {
+		replica.Retries = primary.Retries
+		replica.RetryDelay = primary.RetryDelay
+		replica.RetryMaxDelay = primary.RetryMaxDelay
+	}
+	replica.Replicas = nil
+	return replica
+}
@@ -0,0 +1,267 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"reflect"
+	"regexp"
+	"syscall"
+	"time"
+
+	mssql "github.com/denisenkom/go-mssqldb"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+	utl "github.com/rafael180496/core-util/utility"
+)
+
+type (
+	/*DialectRetry : interfaz opcional de un dialecto para clasificar los errores transitorios del driver
+	(conexion perdida, victima de deadlock, fallo de serializacion) que se pueden reintentar*/
+	DialectRetry interface {
+		Transient(err error) bool
+	}
+	/*DialectConflict : interfaz opcional de un dialecto para los errores de deadlock o serializacion,
+	la base de datos ya revirtio la transaccion y se puede repetir completa aunque se hayan enviado sentencias*/
+	DialectConflict interface {
+		Conflict(err error) bool
+	}
+	/*txSent : transaccion de inTx que marca cuando se envia una sentencia,
+	despues de enviar una sentencia la transaccion ya no se reintenta*/
+	txSent struct {
+		*sqlx.Tx
+		sent bool
+	}
+)
+
+var (
+	/*oraCode : codigo de error de oracle en el mensaje del driver*/
+	oraCode = regexp.MustCompile(`ORA-(\d{5})`)
+	/*oraTransient : errores de oracle de conexion, deadlock y serializacion*/
+	oraTransient = []string{"00060", "08177", "03113", "03114", "03135", "12170", "12514", "12528", "12537", "12541", "01033", "01034", "01089"}
+	/*pqTransient : errores de postgres de deadlock, serializacion y cierre del servidor*/
+	pqTransient = []string{"40001", "40P01", "57P01", "57P02", "57P03"}
+	/*mysqlTransient : errores de mysql de deadlock, espera de bloqueo y conexion perdida*/
+	mysqlTransient = []uint16{1205, 1213, 2006, 2013}
+	/*sqlserTransient : errores de sql server de victima de deadlock y base no disponible*/
+	sqlserTransient = []int32{1205, 40197, 40501, 40613}
+	/*oraConflict : errores de oracle de deadlock y serializacion*/
+	oraConflict = []string{"00060", "08177"}
+	/*pqConflict : errores de postgres de deadlock y serializacion*/
+	pqConflict = []string{"40001", "40P01"}
+	/*mysqlConflict : errores de mysql de deadlock y espera de bloqueo*/
+	mysqlConflict = []uint16{1205, 1213}
+	/*sqlserConflict : errores de sql server de victima de deadlock*/
+	sqlserConflict = []int32{1205}
+)
+
+/*IsTransient : indica si un error del tipo de conexion tp se puede reintentar,
+los errores de red y conexiones invalidas son transitorios en todos los dialectos*/
+func IsTransient(tp string, err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var errNet net.Error
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.EPIPE) ||
+		errors.As(err, &errNet) {
+		return true
+	}
+	d, ok := GetDialect(tp)
+	if !ok {
+		return false
+	}
+	retry, ok := d.(DialectRetry)
+	return ok && retry.Transient(err)
+}
+
+/*Transient : indica si un error de la conexion se puede reintentar*/
+func (p *StConect) Transient(err error) bool {
+	return IsTransient(p.Conexion.TP, err)
+}
+
+/*IsConflict : indica si un error del tipo de conexion tp es un deadlock o fallo de serializacion,
+a diferencia de una conexion perdida la transaccion se puede repetir despues de enviar sentencias*/
+func IsConflict(tp string, err error) bool {
+	if err == nil {
+		return false
+	}
+	d, ok := GetDialect(tp)
+	if !ok {
+		return false
+	}
+	conflict, ok := d.(DialectConflict)
+	return ok && conflict.Conflict(err)
+}
+
+/*Transient : errores de postgres de la clase 08 (conexion), deadlock y serializacion*/
+func (p *dialectPost) Transient(err error) bool {
+	var errPq *pq.Error
+	if !errors.As(err, &errPq) {
+		return false
+	}
+	return errPq.Code.Class() == "08" || utl.InStr(string(errPq.Code), pqTransient...)
+}
+
+/*Transient : errores de mysql de deadlock, espera de bloqueo y conexion perdida*/
+func (p *dialectMysql) Transient(err error) bool {
+	if errors.Is(err, mysql.ErrInvalidConn) {
+		return true
+	}
+	var errMysql *mysql.MySQLError
+	if !errors.As(err, &errMysql) {
+		return false
+	}
+	for _, code := range mysqlTransient {
+		if errMysql.Number == code {
+			return true
+		}
+	}
+	return false
+}
+
+/*Transient : errores de sql server de victima de deadlock y base no disponible*/
+func (p *dialectSqlser) Transient(err error) bool {
+	var errMssql mssql.Error
+	if !errors.As(err, &errMssql) {
+		return false
+	}
+	for _, code := range sqlserTransient {
+		if errMssql.Number == code {
+			return true
+		}
+	}
+	return false
+}
+
+/*Transient : errores ORA de conexion, deadlock y serializacion*/
+func (p *dialectOra) Transient(err error) bool {
+	code := oraCode.FindStringSubmatch(err.Error())
+	return len(code) > 1 && utl.InStr(code[1], oraTransient...)
+}
+
+/*Transient : base de datos ocupada o bloqueada*/
+func (p *dialectSQLLite) Transient(err error) bool {
+	var errSqlite sqlite3.Error
+	if !errors.As(err, &errSqlite) {
+		return false
+	}
+	return errSqlite.Code == sqlite3.ErrBusy || errSqlite.Code == sqlite3.ErrLocked
+}
+
+/*Conflict : errores de postgres de deadlock y serializacion*/
+func (p *dialectPost) Conflict(err error) bool {
+	var errPq *pq.Error
+	return errors.As(err, &errPq) && utl.InStr(string(errPq.Code), pqConflict...)
+}
+
+/*Conflict : errores de mysql de deadlock y espera de bloqueo*/
+func (p *dialectMysql) Conflict(err error) bool {
+	var errMysql *mysql.MySQLError
+	if !errors.As(err, &errMysql) {
+		return false
+	}
+	for _, code := range mysqlConflict {
+		if errMysql.Number == code {
+			return true
+		}
+	}
+	return false
+}
+
+/*Conflict : error de sql server de victima de deadlock*/
+func (p *dialectSqlser) Conflict(err error) bool {
+	var errMssql mssql.Error
+	if !errors.As(err, &errMssql) {
+		return false
+	}
+	for _, code := range sqlserConflict {
+		if errMssql.Number == code {
+			return true
+		}
+	}
+	return false
+}
+
+/*Conflict : errores ORA de deadlock y serializacion*/
+func (p *dialectOra) Conflict(err error) bool {
+	code := oraCode.FindStringSubmatch(err.Error())
+	return len(code) > 1 && utl.InStr(code[1], oraConflict...)
+}
+
+/*Conflict : base de datos ocupada o bloqueada*/
+func (p *dialectSQLLite) Conflict(err error) bool {
+	return p.Transient(err)
+}
+
+/*retry : ejecuta fn reintentando los errores transitorios hasta Retries veces con espera exponencial,
+la espera se detiene si el contexto se cancela*/
+func (p *StConect) retry(ctx context.Context, fn func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= p.Conexion.Retries || !p.Transient(err) {
+			return err
+		}
+		timer := time.NewTimer(p.Conexion.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+/*backoff : espera del reintento attempt, crece al doble hasta RetryMaxDelay
+con una variacion aleatoria entre la mitad y el total de la espera*/
+func (p *StCadConect) backoff(attempt int) time.Duration {
+	delay := time.Duration(utl.ReturnIf(p.RetryDelay > 0, p.RetryDelay, RETRYDELAY).(int)) * time.Millisecond
+	limit := time.Duration(utl.ReturnIf(p.RetryMaxDelay > 0, p.RetryMaxDelay, RETRYMAXDELAY).(int)) * time.Millisecond
+	for i := 0; i < attempt && delay < limit; i++ {
+		delay *= 2
+	}
+	if delay > limit {
+		delay = limit
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+/*ExecContext : marca la transaccion como enviada y ejecuta la sentencia*/
+func (p *txSent) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	p.sent = true
+	return p.Tx.ExecContext(ctx, query, args...)
+}
+
+/*QueryContext : marca la transaccion como enviada y ejecuta la consulta*/
+func (p *txSent) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	p.sent = true
+	return p.Tx.QueryContext(ctx, query, args...)
+}
+
+/*QueryxContext : marca la transaccion como enviada y ejecuta la consulta*/
+func (p *txSent) QueryxContext(ctx context.Context, query string, args ...interface{}) (*sqlx.Rows, error) {
+	p.sent = true
+	return p.Tx.QueryxContext(ctx, query, args...)
+}
+
+/*QueryRowxContext : marca la transaccion como enviada y ejecuta la consulta*/
+func (p *txSent) QueryRowxContext(ctx context.Context, query string, args ...interface{}) *sqlx.Row {
+	p.sent = true
+	return p.Tx.QueryRowxContext(ctx, query, args...)
+}
+
+/*resetRows : regresa el destino de QueryStruct a su tamaño original antes de un reintento
+porque sqlx agrega las filas al slice*/
+func resetRows(dest interface{}, size int64) {
+	vl := reflect.Indirect(reflect.ValueOf(dest))
+	if vl.Kind() == reflect.Slice && vl.CanSet() && int64(vl.Len()) > size {
+		vl.SetLen(int(size))
+	}
+}
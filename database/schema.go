@@ -0,0 +1,231 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	utl "github.com/rafael180496/core-util/utility"
+)
+
+type (
+	/*StColumn : columna de una tabla leida del catalogo de la base de datos
+	Type : tipo nativo de la base de datos
+	Tp : tipo core mapeado del tipo nativo
+	Key : la columna pertenece a la llave primaria
+	Length : largo maximo de los textos, 0 sin limite*/
+	StColumn struct {
+		Name     string `json:"name"`
+		Type     string `json:"type"`
+		Tp       TpCore `json:"tp"`
+		Nullable bool   `json:"nullable"`
+		Default  string `json:"default"`
+		Key      bool   `json:"key"`
+		Position int    `json:"position"`
+		Length   int    `json:"length"`
+	}
+	/*StIndex : indice de una tabla con sus columnas en orden*/
+	StIndex struct {
+		Name    string   `json:"name"`
+		Columns []string `json:"columns"`
+		Unique  bool     `json:"unique"`
+	}
+)
+
+/*FindTpCore : mapea el tipo nativo de una columna (VARCHAR2,bigint,datetime...) a un TpCore,
+si no corresponde a ningun tipo conocido regresa STTP*/
+func FindTpCore(native string) TpCore {
+	for _, item := range TPNATIVE {
+		if item.Exp.MatchString(native) {
+			return item.Tp
+		}
+	}
+	return STTP
+}
+
+/*ListTables : envia los nombres de las tablas del esquema actual
+	indConect = true deja la conexion abierta
+*/
+func (p *StConect) ListTables(indConect bool) ([]string, error) {
+	return p.ListTablesCtx(context.Background(), indConect)
+}
+
+/*ListTablesCtx : igual que ListTables pero con un contexto de cancelacion*/
+func (p *StConect) ListTablesCtx(ctx context.Context, indConect bool) ([]string, error) {
+	var result []string
+	d, err := p.dialectSchema()
+	if err != nil {
+		return result, err
+	}
+	rows, err := p.QueryMapCtx(ctx, StQuery{Querie: d.ListTablesQuery()}, 0, indConect, false)
+	if err != nil {
+		return result, err
+	}
+	for _, item := range rows {
+		row := item.UpperKey()
+		name, _ := row.ToString("TABLE_NAME")
+		result = append(result, name)
+	}
+	return result, nil
+}
+
+/*DescribeTable : envia las columnas de una tabla en orden marcando las de la llave primaria
+	indConect = true deja la conexion abierta
+*/
+func (p *StConect) DescribeTable(table string, indConect bool) ([]StColumn, error) {
+	return p.DescribeTableCtx(context.Background(), table, indConect)
+}
+
+/*DescribeTableCtx : igual que DescribeTable pero con un contexto de cancelacion*/
+func (p *StConect) DescribeTableCtx(ctx context.Context, table string, indConect bool) ([]StColumn, error) {
+	var result []StColumn
+	d, err := p.dialectSchema()
+	if err != nil {
+		return result, err
+	}
+	keys, err := p.PrimaryKeyCtx(ctx, table, true)
+	if err != nil {
+		return result, err
+	}
+	rows, err := p.QueryMapCtx(ctx, tableQuery(d.ColumnsQuery(), table), 0, indConect, false)
+	if err != nil {
+		return result, err
+	}
+	if len(rows) <= 0 {
+		return result, fmt.Errorf("the table %s does not exist", table)
+	}
+	for _, item := range rows {
+		row := item.UpperKey()
+		name, _ := row.ToString("COLUMN_NAME")
+		native, _ := row.ToString("DATA_TYPE")
+		nullable, _ := row.ToString("NULLABLE")
+		position, _ := row.ToInt("POSITION")
+		length, _ := row.ToInt("MAX_LENGTH")
+		column := StColumn{
+			Name:     name,
+			Type:     native,
+			Tp:       FindTpCore(native),
+			Nullable: strings.HasPrefix(strings.ToUpper(utl.Trim(nullable)), "Y"),
+			Key:      inCols(name, keys),
+			Position: position,
+			Length:   utl.ReturnIf(length > 0, length, 0).(int),
+		}
+		if row.ValidColum("DEFAULT_VALUE") {
+			column.Default, _ = row.ToString("DEFAULT_VALUE")
+			column.Default = strings.TrimSpace(column.Default)
+		}
+		result = append(result, column)
+	}
+	return result, nil
+}
+
+/*PrimaryKey : envia las columnas de la llave primaria de una tabla en orden
+	indConect = true deja la conexion abierta
+*/
+func (p *StConect) PrimaryKey(table string, indConect bool) ([]string, error) {
+	return p.PrimaryKeyCtx(context.Background(), table, indConect)
+}
+
+/*PrimaryKeyCtx : igual que PrimaryKey pero con un contexto de cancelacion*/
+func (p *StConect) PrimaryKeyCtx(ctx context.Context, table string, indConect bool) ([]string, error) {
+	var result []string
+	d, err := p.dialectSchema()
+	if err != nil {
+		return result, err
+	}
+	rows, err := p.QueryMapCtx(ctx, tableQuery(d.PrimaryKeyQuery(), table), 0, indConect, false)
+	if err != nil {
+		return result, err
+	}
+	for _, item := range rows {
+		row := item.UpperKey()
+		name, _ := row.ToString("COLUMN_NAME")
+		result = append(result, name)
+	}
+	return result, nil
+}
+
+/*Indexes : envia los indices de una tabla con sus columnas
+	indConect = true deja la conexion abierta
+*/
+func (p *StConect) Indexes(table string, indConect bool) ([]StIndex, error) {
+	return p.IndexesCtx(context.Background(), table, indConect)
+}
+
+/*IndexesCtx : igual que Indexes pero con un contexto de cancelacion*/
+func (p *StConect) IndexesCtx(ctx context.Context, table string, indConect bool) ([]StIndex, error) {
+	var result []StIndex
+	d, err := p.dialectSchema()
+	if err != nil {
+		return result, err
+	}
+	rows, err := p.QueryMapCtx(ctx, tableQuery(d.IndexesQuery(), table), 0, indConect, false)
+	if err != nil {
+		return result, err
+	}
+	pos := make(map[string]int)
+	for _, item := range rows {
+		row := item.UpperKey()
+		name, _ := row.ToString("INDEX_NAME")
+		column, _ := row.ToString("COLUMN_NAME")
+		i, ok := pos[name]
+		if !ok {
+			i = len(result)
+			pos[name] = i
+			result = append(result, StIndex{
+				Name:   name,
+				Unique: toFlag(row["IS_UNIQUE"]),
+			})
+		}
+		result[i].Columns = append(result[i].Columns, column)
+	}
+	return result, nil
+}
+
+/*dialectSchema : envia el dialecto de la conexion validando que permita leer el catalogo*/
+func (p *StConect) dialectSchema() (DialectSchema, error) {
+	d, err := p.Dialect()
+	if err != nil {
+		return nil, err
+	}
+	schema, ok := d.(DialectSchema)
+	if !ok {
+		return nil, fmt.Errorf("the dialect %s does not support introspection", d.Name())
+	}
+	return schema, nil
+}
+
+/*tableQuery : arma el StQuery de catalogo con el parametro TABLENAME*/
+func tableQuery(query, table string) StQuery {
+	return StQuery{
+		Querie: query,
+		Args: map[string]interface{}{
+			"TABLENAME": table,
+		},
+	}
+}
+
+/*inCols : valida si la columna esta en la lista sin importar mayusculas*/
+func inCols(col string, cols []string) bool {
+	for _, item := range cols {
+		if strings.EqualFold(item, col) {
+			return true
+		}
+	}
+	return false
+}
+
+/*toFlag : convierte los indicadores del catalogo (bool,1/0,Y/N) a bool*/
+func toFlag(v interface{}) bool {
+	switch vl := v.(type) {
+	case bool:
+		return vl
+	case string:
+		vl = strings.ToUpper(utl.Trim(vl))
+		return vl == "1" || strings.HasPrefix(vl, "Y") || strings.HasPrefix(vl, "T")
+	case nil:
+		return false
+	default:
+		return utl.ToInt64(vl) != 0
+	}
+}
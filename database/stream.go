@@ -0,0 +1,180 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	utl "github.com/rafael180496/core-util/utility"
+)
+
+type (
+	/*StProgress : avance de un extractor en ProcessParallel
+	Read : filas leidas de la entrada
+	Written : filas confirmadas en la salida
+	Done : el extractor termino*/
+	StProgress struct {
+		Table   string        `json:"table"`
+		Read    int           `json:"read"`
+		Written int           `json:"written"`
+		Elapsed time.Duration `json:"elapsed"`
+		Done    bool          `json:"done"`
+	}
+)
+
+/*
+ProcessParallel : igual que Process pero los extractores completos se ejecutan al mismo tiempo con un
+maximo de Workers hilos y cada uno escribe sus filas en la salida por bloques de Chunk filas sin cargar
+toda la tabla en memoria, si un extractor falla se cancelan los demas. Los bloques ya escritos no se revierten.
+Los extractores incrementales y Diff se procesan despues igual que en Process.
+*/
+func (p *StMerge) ProcessParallel(ctx context.Context) error {
+	if len(p.ItemsExt) <= 0 {
+		return fmt.Errorf("los extractores estan vacio")
+	}
+	err := p.CnxIn.ConCtx(ctx)
+	if err != nil {
+		return err
+	}
+	defer p.CnxIn.release()
+	err = p.CnxOut.ConCtx(ctx)
+	if err != nil {
+		return err
+	}
+	defer p.CnxOut.release()
+	if p.InDelIn {
+		err = p.CnxIn.ExecCtx(ctx, p.DelsqlIn, true)
+		if err != nil {
+			return err
+		}
+	}
+	if p.InDelOut {
+		err = p.CnxOut.ExecCtx(ctx, p.DelsqlOut, true)
+		if err != nil {
+			return err
+		}
+	}
+	workers := p.Workers
+	if workers <= 0 {
+		workers = utl.MaxCPUtask()
+	}
+	workers = utl.MaxCPUtaskLimit(workers)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		mu       sync.Mutex
+		errFirst error
+	)
+	progress := func(item StProgress) {
+		if p.Progress == nil {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		p.Progress(item)
+	}
+	sem := make(chan struct{}, workers)
+	for _, v := range p.ItemsExt {
+		if utl.IsNilStr(v.Watermark) || v.Diff {
+			continue
+		}
+		wg.Add(1)
+		go func(v StExt) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+			err := p.streamExt(ctx, v, progress)
+			if err != nil {
+				once.Do(func() {
+					errFirst = err
+					cancel()
+				})
+			}
+		}(v)
+	}
+	wg.Wait()
+	if errFirst != nil {
+		return errFirst
+	}
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+	return p.processSync()
+}
+
+/*streamExt : lee las filas de un extractor y las inserta en la salida por bloques,
+las copias de las conexiones se marcan compartidas para que un error de un hilo no cierre el pool de los demas*/
+func (p *StMerge) streamExt(ctx context.Context, v StExt, progress func(StProgress)) error {
+	cnxIn, cnxOut := p.CnxIn, p.CnxOut
+	cnxIn.shared, cnxOut.shared = true, true
+	chunk := utl.ReturnIf(p.Chunk > 0, p.Chunk, CHUNKROWS).(int)
+	start := time.Now()
+	status := StProgress{Table: v.TableNameOut}
+	transform, err := v.transformer()
+	if err != nil {
+		return err
+	}
+	iter, err := cnxIn.QueryIterCtx(ctx, v.SQLIn, true)
+	if err != nil {
+		return err
+	}
+	defer iter.Close()
+	var rows []StData
+	flush := func() error {
+		if len(rows) <= 0 {
+			return nil
+		}
+		data := NewDataTable(v.TableNameOut, rows, v.Index)
+		data.SetBatch(p.Batch)
+		err := cnxOut.ExecDatatableCtx(ctx, data, INSERT, true)
+		if err != nil {
+			return err
+		}
+		status.Written += len(rows)
+		status.Elapsed = time.Since(start)
+		progress(status)
+		rows = nil
+		return nil
+	}
+	for iter.Next() {
+		row := iter.Data()
+		for _, col := range iter.Columns() {
+			if _, ok := row[col]; !ok {
+				row[col] = nil
+			}
+		}
+		status.Read++
+		row, keep, err := transform(row)
+		if err != nil {
+			return fmt.Errorf("row %d: %s", status.Read, err.Error())
+		}
+		if !keep {
+			continue
+		}
+		rows = append(rows, row)
+		if len(rows) >= chunk {
+			err = flush()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if iter.Err() != nil {
+		return iter.Err()
+	}
+	err = flush()
+	if err != nil {
+		return err
+	}
+	status.Elapsed = time.Since(start)
+	status.Done = true
+	progress(status)
+	return nil
+}
@@ -0,0 +1,252 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	utl "github.com/rafael180496/core-util/utility"
+)
+
+type (
+	/*StWatermark : ultimo valor de la columna de marca sincronizado de un extractor
+	Tp : tipo core del valor para enviarlo como parametro con su tipo original*/
+	StWatermark struct {
+		Value     string `json:"value"`
+		Tp        TpCore `json:"tp"`
+		UpdatedAt string `json:"updatedat"`
+	}
+	/*StSyncState : almacena las marcas de los extractores incrementales por el nombre de la tabla de salida*/
+	StSyncState interface {
+		/*Load : envia la marca guardada, false si el extractor no se ha sincronizado*/
+		Load(key string) (StWatermark, bool, error)
+		/*Save : guarda la marca despues de confirmar los datos en la salida*/
+		Save(key string, mark StWatermark) error
+	}
+	/*StStateTable : guarda las marcas en una tabla de la base de datos, se crea si no existe*/
+	StStateTable struct {
+		Cnx   *StConect
+		Table string
+	}
+	/*StStateFile : guarda las marcas en un archivo json*/
+	StStateFile struct {
+		Path string
+		mu   sync.Mutex
+	}
+)
+
+/*NewStateTable : crea el almacenamiento de marcas en una tabla, si table esta vacio usa TABLESTATE*/
+func NewStateTable(cnx *StConect, table string) *StStateTable {
+	return &StStateTable{
+		Cnx:   cnx,
+		Table: utl.ReturnIf(utl.IsNilStr(table), table, TABLESTATE).(string),
+	}
+}
+
+/*NewStateFile : crea el almacenamiento de marcas en un archivo json*/
+func NewStateFile(path string) *StStateFile {
+	return &StStateFile{Path: path}
+}
+
+/*Load : lee la marca de la tabla de estados*/
+func (p *StStateTable) Load(key string) (StWatermark, bool, error) {
+	var mark StWatermark
+	err := p.createTable()
+	if err != nil {
+		return mark, false, err
+	}
+	rows, err := p.Cnx.QueryMap(StQuery{
+		Querie: fmt.Sprintf(`SELECT MARK_VALUE, MARK_TP, UPDATED_AT FROM %s WHERE NAME = :NAME`, p.Table),
+		Args: map[string]interface{}{
+			"NAME": key,
+		},
+		Primary: true,
+	}, 1, true, true)
+	if err != nil || len(rows) <= 0 {
+		return mark, false, err
+	}
+	row := rows[0].UpperKey()
+	mark.Value, _ = row.ToString("MARK_VALUE")
+	tp, _ := row.ToString("MARK_TP")
+	mark.Tp = TpCore(tp)
+	mark.UpdatedAt, _ = row.ToString("UPDATED_AT")
+	return mark, true, nil
+}
+
+/*Save : guarda la marca en la tabla de estados con un upsert*/
+func (p *StStateTable) Save(key string, mark StWatermark) error {
+	err := p.createTable()
+	if err != nil {
+		return err
+	}
+	data := NewDataTable(p.Table, []StData{{
+		"NAME":       key,
+		"MARK_VALUE": mark.Value,
+		"MARK_TP":    string(mark.Tp),
+		"UPDATED_AT": mark.UpdatedAt,
+	}}, nil)
+	err = data.AddIndexs("NAME")
+	if err != nil {
+		return err
+	}
+	return p.Cnx.ExecDatatable(data, UPSERT, true)
+}
+
+/*createTable : crea la tabla de estados si no existe*/
+func (p *StStateTable) createTable() error {
+	if p.Cnx == nil {
+		return fmt.Errorf("the connection is nil")
+	}
+	if p.Cnx.ValidTable(p.Table) {
+		return nil
+	}
+	script, ok := CREATESTATE[p.Cnx.Conexion.TP]
+	if !ok {
+		return fmt.Errorf("type database not supports")
+	}
+	_, err := p.Cnx.ExecNative(fmt.Sprintf(script, p.Table), true)
+	return err
+}
+
+/*Load : lee la marca del archivo json*/
+func (p *StStateFile) Load(key string) (StWatermark, bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	marks, err := p.read()
+	if err != nil {
+		return StWatermark{}, false, err
+	}
+	mark, ok := marks[key]
+	return mark, ok, nil
+}
+
+/*Save : guarda la marca reemplazando el archivo json completo*/
+func (p *StStateFile) Save(key string, mark StWatermark) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	marks, err := p.read()
+	if err != nil {
+		return err
+	}
+	marks[key] = mark
+	data, err := json.MarshalIndent(marks, "", "  ")
+	if err != nil {
+		return err
+	}
+	temp := p.Path + ".tmp"
+	err = os.WriteFile(temp, data, 0666)
+	if err != nil {
+		return err
+	}
+	return os.Rename(temp, p.Path)
+}
+
+/*read : lee todas las marcas del archivo, si no existe regresa un map vacio*/
+func (p *StStateFile) read() (map[string]StWatermark, error) {
+	marks := make(map[string]StWatermark)
+	data, err := os.ReadFile(filepath.Clean(p.Path))
+	if os.IsNotExist(err) {
+		return marks, nil
+	}
+	if err != nil {
+		return marks, err
+	}
+	if len(data) <= 0 {
+		return marks, nil
+	}
+	return marks, json.Unmarshal(data, &marks)
+}
+
+/*Arg : convierte la marca a su tipo original para enviarla como parametro*/
+func (p StWatermark) Arg() interface{} {
+	switch p.Tp {
+	case INTP:
+		if num, err := strconv.ParseInt(p.Value, 10, 64); err == nil {
+			return num
+		}
+	case FLTP:
+		if num, err := strconv.ParseFloat(p.Value, 64); err == nil {
+			return num
+		}
+	case DTTP:
+		date, err := time.Parse(time.RFC3339Nano, p.Value)
+		if err != nil {
+			return p.Value
+		}
+		return date
+	}
+	return p.Value
+}
+
+/*newWatermark : crea la marca de un valor leido de la base de datos*/
+func newWatermark(vl interface{}) StWatermark {
+	mark := StWatermark{
+		UpdatedAt: utl.ToDateStr(time.Now()),
+	}
+	switch data := vl.(type) {
+	case time.Time:
+		mark.Tp = DTTP
+		mark.Value = data.Format(time.RFC3339Nano)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		mark.Tp = INTP
+		mark.Value = utl.ToString(data)
+	case float32, float64:
+		mark.Tp = FLTP
+		mark.Value = utl.ToString(data)
+	default:
+		mark.Tp = STTP
+		mark.Value = utl.ToString(data)
+	}
+	return mark
+}
+
+/*compareMark : compara dos valores de la columna de marca, -1 si a es menor, 0 si son iguales y 1 si a es mayor*/
+func compareMark(a, b interface{}) int {
+	switch da := a.(type) {
+	case time.Time:
+		if db, ok := b.(time.Time); ok {
+			return da.Compare(db)
+		}
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		switch b.(type) {
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+			fa, fb := utl.ToFloat64(a), utl.ToFloat64(b)
+			return utl.ReturnIf(fa < fb, -1, utl.ReturnIf(fa > fb, 1, 0)).(int)
+		}
+	}
+	/*los numeric y decimal de algunos drivers llegan como texto, se comparan como numero si ambos lo son*/
+	ta, tb := diffValue(a), diffValue(b)
+	fa, errA := strconv.ParseFloat(ta, 64)
+	fb, errB := strconv.ParseFloat(tb, 64)
+	if errA == nil && errB == nil {
+		return utl.ReturnIf(fa < fb, -1, utl.ReturnIf(fa > fb, 1, 0)).(int)
+	}
+	return strings.Compare(ta, tb)
+}
+
+/*markQuery : filtra el query del extractor con las filas mayores a la marca guardada*/
+func markQuery(query StQuery, column string, mark StWatermark, indMark bool) StQuery {
+	args := make(map[string]interface{})
+	for key, vl := range query.Args {
+		args[key] = vl
+	}
+	sqltemp := strings.TrimSuffix(strings.TrimSpace(query.Querie), ";")
+	if !indMark {
+		return StQuery{Querie: sqltemp, Args: args, Timeout: query.Timeout, Primary: query.Primary}
+	}
+	if i := orderByIndex(sqltemp); i >= 0 {
+		sqltemp = sqltemp[:i]
+	}
+	args["WATERMARK"] = mark.Arg()
+	return StQuery{
+		Querie:  fmt.Sprintf("SELECT * FROM (%s) WMK WHERE WMK.%s > :WATERMARK", strings.TrimSpace(sqltemp), column),
+		Args:    args,
+		Timeout: query.Timeout,
+		Primary: query.Primary,
+	}
+}
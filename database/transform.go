@@ -0,0 +1,227 @@
+package database
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	utl "github.com/rafael180496/core-util/utility"
+)
+
+type (
+	/*StTransform : transformacion de una fila entre la extraccion y la carga,
+	regresa la fila transformada y false si la fila se debe descartar*/
+	StTransform func(row StData) (StData, bool, error)
+	/*
+		StStep : paso de transformacion declarado en configuracion
+		Type : RENAME,DROP,COMPUTE,CAST,FILTER,MASK,HASH
+		Column o Columns : columnas a las que aplica el paso
+		To : nuevo nombre de la columna en RENAME
+		Value : plantilla con {COLUMNA} en COMPUTE, valor a comparar en FILTER, caracter en MASK y sal en HASH
+		Tp : tipo core en CAST
+		Op : operador de FILTER =,!=,>,>=,<,<=,NULL,NOTNULL
+		Keep : caracteres finales visibles en MASK, siempre se oculta al menos un caracter
+	*/
+	StStep struct {
+		Type    string   `json:"type"`
+		Column  string   `json:"column"`
+		Columns []string `json:"columns"`
+		To      string   `json:"to"`
+		Value   string   `json:"value"`
+		Tp      TpCore   `json:"tp"`
+		Op      string   `json:"op"`
+		Keep    int      `json:"keep"`
+	}
+)
+
+var (
+	/*templateFor : columnas de la plantilla de COMPUTE {COLUMNA}*/
+	templateFor = regexp.MustCompile(`\{([A-Za-z0-9_]+)\}`)
+)
+
+/*Transform : compila el paso de configuracion a una transformacion de filas*/
+func (p StStep) Transform() (StTransform, error) {
+	cols := p.cols()
+	if len(cols) <= 0 {
+		return nil, fmt.Errorf("the step %s does not have columns", p.Type)
+	}
+	switch strings.ToUpper(p.Type) {
+	case STEPRENAME:
+		to := strings.ToUpper(utl.Trim(p.To))
+		if len(cols) != 1 || to == "" {
+			return nil, fmt.Errorf("the step %s needs one column and the new name", p.Type)
+		}
+		return func(row StData) (StData, bool, error) {
+			if vl, ok := row[cols[0]]; ok {
+				delete(row, cols[0])
+				row[to] = vl
+			}
+			return row, true, nil
+		}, nil
+	case STEPDROP:
+		return func(row StData) (StData, bool, error) {
+			for _, col := range cols {
+				delete(row, col)
+			}
+			return row, true, nil
+		}, nil
+	case STEPCOMPUTE:
+		return func(row StData) (StData, bool, error) {
+			row[cols[0]] = templateFor.ReplaceAllStringFunc(p.Value, func(item string) string {
+				vl := row[strings.ToUpper(item[1:len(item)-1])]
+				return utl.ReturnIf(vl == nil, "", utl.ToString(vl)).(string)
+			})
+			return row, true, nil
+		}, nil
+	case STEPCAST:
+		if FindTp("", p.Tp) == nil {
+			return nil, fmt.Errorf("invalid type %s in the step %s", p.Tp, p.Type)
+		}
+		return func(row StData) (StData, bool, error) {
+			for _, col := range cols {
+				if vl, ok := row[col]; ok && vl != nil {
+					row[col] = FindTp(vl, p.Tp)
+				}
+			}
+			return row, true, nil
+		}, nil
+	case STEPFILTER:
+		op := strings.ToUpper(utl.Trim(p.Op))
+		if !utl.InStr(op, "=", "!=", ">", ">=", "<", "<=", "NULL", "NOTNULL") {
+			return nil, fmt.Errorf("invalid operator %s in the step %s", p.Op, p.Type)
+		}
+		return func(row StData) (StData, bool, error) {
+			return row, filterValue(row[cols[0]], op, p.Value), nil
+		}, nil
+	case STEPMASK:
+		mask := utl.ReturnIf(p.Value == "", "*", p.Value).(string)
+		return func(row StData) (StData, bool, error) {
+			for _, col := range cols {
+				if vl, ok := row[col]; ok && vl != nil {
+					row[col] = maskValue(utl.ToString(vl), mask, p.Keep)
+				}
+			}
+			return row, true, nil
+		}, nil
+	case STEPHASH:
+		return func(row StData) (StData, bool, error) {
+			for _, col := range cols {
+				if vl, ok := row[col]; ok && vl != nil {
+					row[col] = utl.GeneredHashSha256(p.Value + utl.ToString(vl))
+				}
+			}
+			return row, true, nil
+		}, nil
+	default:
+		return nil, fmt.Errorf("invalid step type %s", p.Type)
+	}
+}
+
+/*cols : columnas del paso en mayusculas*/
+func (p StStep) cols() []string {
+	var cols []string
+	for _, col := range append([]string{p.Column}, p.Columns...) {
+		col = strings.ToUpper(utl.Trim(col))
+		if col != "" {
+			cols = append(cols, col)
+		}
+	}
+	return cols
+}
+
+/*TransformRows : aplica los pasos y transformaciones del extractor a las filas descartando las filtradas*/
+func (p *StExt) TransformRows(rows []StData) ([]StData, error) {
+	fn, err := p.transformer()
+	if err != nil {
+		return nil, err
+	}
+	var result []StData
+	for i, row := range rows {
+		row, keep, err := fn(row)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %s", i+1, err.Error())
+		}
+		if keep {
+			result = append(result, row)
+		}
+	}
+	return result, nil
+}
+
+/*transformer : compila los pasos de configuracion y las transformaciones en go en una sola funcion por fila,
+los pasos se aplican primero y las columnas llegan en mayusculas*/
+func (p *StExt) transformer() (StTransform, error) {
+	var fns []StTransform
+	for _, step := range p.Steps {
+		fn, err := step.Transform()
+		if err != nil {
+			return nil, err
+		}
+		fns = append(fns, fn)
+	}
+	for _, fn := range p.Transforms {
+		if fn != nil {
+			fns = append(fns, fn)
+		}
+	}
+	return func(row StData) (StData, bool, error) {
+		row = row.UpperKey()
+		for _, fn := range fns {
+			var (
+				keep bool
+				err  error
+			)
+			row, keep, err = fn(row)
+			if err != nil || !keep {
+				return row, false, err
+			}
+		}
+		return row, true, nil
+	}, nil
+}
+
+/*filterValue : compara el valor de la columna con el valor del filtro como numero si ambos son numericos*/
+func filterValue(vl interface{}, op, value string) bool {
+	switch op {
+	case "NULL":
+		return vl == nil
+	case "NOTNULL":
+		return vl != nil
+	}
+	if vl == nil {
+		return false
+	}
+	var cmp int
+	text := diffValue(vl)
+	numA, errA := strconv.ParseFloat(text, 64)
+	numB, errB := strconv.ParseFloat(value, 64)
+	if errA == nil && errB == nil {
+		cmp = utl.ReturnIf(numA < numB, -1, utl.ReturnIf(numA > numB, 1, 0)).(int)
+	} else {
+		cmp = strings.Compare(text, value)
+	}
+	switch op {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	default:
+		return cmp <= 0
+	}
+}
+
+/*maskValue : reemplaza el texto con la mascara dejando visibles los ultimos keep caracteres,
+si el texto no es mas largo que keep se oculta al menos el primer caracter*/
+func maskValue(text, mask string, keep int) string {
+	runes := []rune(text)
+	keep = utl.ReturnIf(keep >= len(runes), len(runes)-1, keep).(int)
+	keep = utl.ReturnIf(keep < 0, 0, keep).(int)
+	return strings.Repeat(mask, len(runes)-keep) + string(runes[len(runes)-keep:])
+}
@@ -0,0 +1,242 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"github.com/jmoiron/sqlx"
+	utl "github.com/rafael180496/core-util/utility"
+)
+
+type (
+	/*StTx : transaccion abierta sobre un StConect que expone el mismo api de consultas y ejecuciones,
+	todo lo que se ejecuta en ella se confirma con Commit o se revierte con Rollback*/
+	StTx struct {
+		Tx  *sqlx.Tx
+		cnx *StConect
+		ctx context.Context
+	}
+)
+
+var (
+	/*savepointFor : formato valido para los nombres de savepoint*/
+	savepointFor = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+/*Begin : abre una transaccion en la base de datos configurada*/
+func (p *StConect) Begin() (*StTx, error) {
+	return p.BeginCtx(context.Background(), nil)
+}
+
+/*BeginCtx : abre una transaccion con un contexto de cancelacion y opciones de aislamiento,
+si el contexto se cancela la transaccion se revierte*/
+func (p *StConect) BeginCtx(ctx context.Context, opts *sql.TxOptions) (*StTx, error) {
+	err := p.ConCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := p.DBGO.BeginTxx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &StTx{
+		Tx:  tx,
+		cnx: p,
+		ctx: ctx,
+	}, nil
+}
+
+/*WithTx : ejecuta la funcion dentro de una transaccion haciendo commit si termina bien
+y rollback si regresa un error o entra en panic*/
+func (p *StConect) WithTx(fn func(tx *StTx) error) error {
+	return p.WithTxCtx(context.Background(), nil, fn)
+}
+
+/*WithTxCtx : igual que WithTx pero con un contexto de cancelacion y opciones de aislamiento*/
+func (p *StConect) WithTxCtx(ctx context.Context, opts *sql.TxOptions, fn func(tx *StTx) error) error {
+	tx, err := p.BeginCtx(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+	err = fn(tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+/*Commit : confirma los cambios de la transaccion*/
+func (p *StTx) Commit() error {
+	return p.Tx.Commit()
+}
+
+/*Rollback : revierte los cambios de la transaccion*/
+func (p *StTx) Rollback() error {
+	return p.Tx.Rollback()
+}
+
+/*Savepoint : crea un punto de guardado dentro de la transaccion*/
+func (p *StTx) Savepoint(name string) error {
+	return p.savepointAux(name, "SAVEPOINT %s", "SAVE TRANSACTION %s")
+}
+
+/*RollbackTo : revierte los cambios hechos despues del punto de guardado*/
+func (p *StTx) RollbackTo(name string) error {
+	return p.savepointAux(name, "ROLLBACK TO SAVEPOINT %s", "ROLLBACK TRANSACTION %s")
+}
+
+/*Release : libera el punto de guardado, en sql server y oracle no existe por lo que no hace nada*/
+func (p *StTx) Release(name string) error {
+	if p.cnx.Conexion.TP == Ora {
+		return nil
+	}
+	return p.savepointAux(name, "RELEASE SAVEPOINT %s", "")
+}
+
+/*savepointAux : ejecuta la sentencia de savepoint estandar o la de sql server segun el tipo de conexion*/
+func (p *StTx) savepointAux(name, format, formatSqlser string) error {
+	if !savepointFor.MatchString(name) {
+		return fmt.Errorf("invalid savepoint name")
+	}
+	if p.cnx.Conexion.TP == Sqlser {
+		format = formatSqlser
+	}
+	if format == "" {
+		return nil
+	}
+	return runStmt(p.ctx, p.cnx.Hooks, newStmt(HOOKEXEC, fmt.Sprintf(format, name), nil, nil), func(querie string, args []interface{}) (int64, error) {
+		return rowsAffected(p.Tx.ExecContext(p.ctx, querie, args...))
+	})
+}
+
+/*NamedIn : procesa los argumentos y sql para agarrar la clausula IN */
+func (p *StTx) NamedIn(query StQuery) (string, []interface{}, error) {
+	return namedIn(p.Tx, query)
+}
+
+/*QueryOne : Ejecuta un querie dentro de la transaccion y devuelve solo una fila*/
+func (p *StTx) QueryOne(query StQuery) (StData, error) {
+	result, err := queryExt(p.ctx, p.Tx, p.cnx.Hooks, query, 1, true)
+	if err != nil {
+		return nil, err
+	}
+	if len(result) <= 0 {
+		return nil, fmt.Errorf("no hay datos en la consulta")
+	}
+	return result[0], nil
+}
+
+/*Query : Ejecuta un querie dentro de la transaccion limitando la cantidad de filas*/
+func (p *StTx) Query(query StQuery, cantrow int) ([]StData, error) {
+	if cantrow <= 0 {
+		return nil, fmt.Errorf("row quantity is zero")
+	}
+	return queryExt(p.ctx, p.Tx, p.cnx.Hooks, query, cantrow, true)
+}
+
+/*QueryMap : Ejecuta un querie dentro de la transaccion
+indLimit = true limite de fila si esta en false desactiva esta opcion*/
+func (p *StTx) QueryMap(query StQuery, cantrow int, indLimit bool) ([]StData, error) {
+	return queryExt(p.ctx, p.Tx, p.cnx.Hooks, query, cantrow, indLimit)
+}
+
+/*QueryJSON : Ejecuta un querie dentro de la transaccion y devuelve un json*/
+func (p *StTx) QueryJSON(query StQuery, cantrow int, indLimit bool) ([]byte, error) {
+	result, err := queryExt(p.ctx, p.Tx, p.cnx.Hooks, query, cantrow, indLimit)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&result)
+}
+
+/*QueryStruct : Ejecuta un query dentro de la transaccion y captura la data con struct*/
+func (p *StTx) QueryStruct(datadest interface{}, query StQuery) error {
+	sqltemp, args, err := p.NamedIn(query)
+	if err != nil {
+		return err
+	}
+	qctx, cancel := query.withTimeout(p.ctx)
+	defer cancel()
+	return runStmt(p.ctx, p.cnx.Hooks, newStmt(HOOKQUERY, sqltemp, args, query.Args), func(querie string, args []interface{}) (int64, error) {
+		err := p.Tx.SelectContext(qctx, datadest, querie, args...)
+		return lenRows(datadest), err
+	})
+}
+
+/*QueryRows : Ejecuta un query dentro de la transaccion y devuelve un puntero de *Rows de sqlx,
+las filas se deben cerrar antes del Commit o Rollback*/
+func (p *StTx) QueryRows(query StQuery) (*sqlx.Rows, error) {
+	sqltemp, args, err := p.NamedIn(query)
+	if err != nil {
+		return nil, err
+	}
+	var filas *sqlx.Rows
+	err = runStmt(p.ctx, p.cnx.Hooks, newStmt(HOOKQUERY, sqltemp, args, query.Args), func(querie string, args []interface{}) (int64, error) {
+		filas, err = p.Tx.QueryxContext(p.ctx, querie, args...)
+		return -1, err
+	})
+	return filas, err
+}
+
+/*Exec : Ejecuta varios StQuery dentro de la transaccion*/
+func (p *StTx) Exec(Data []StQuery) error {
+	if len(Data) <= 0 {
+		return fmt.Errorf("number of shares less than or equal to zeros")
+	}
+	return execExt(p.ctx, p.Tx, p.cnx.Hooks, Data, "", false)
+}
+
+/*ExecOne : Ejecuta un StQuery dentro de la transaccion*/
+func (p *StTx) ExecOne(Data StQuery) error {
+	return execExt(p.ctx, p.Tx, p.cnx.Hooks, []StQuery{Data}, "", false)
+}
+
+/*ExecValid : Ejecuta varios StQuery dentro de la transaccion validando el tipo de accion*/
+func (p *StTx) ExecValid(Data []StQuery, tipacc string) error {
+	if len(Data) <= 0 {
+		return fmt.Errorf("number of shares less than or equal to zeros")
+	}
+	return execExt(p.ctx, p.Tx, p.cnx.Hooks, Data, tipacc, true)
+}
+
+/*ExecNative : ejecuta la funcion nativa del paquete sql dentro de la transaccion*/
+func (p *StTx) ExecNative(querie string, args ...interface{}) (sql.Result, error) {
+	if !utl.IsNilStr(querie) {
+		return nil, utl.StrErr("El querie esta vacio")
+	}
+	var rel sql.Result
+	err := runStmt(p.ctx, p.cnx.Hooks, newStmt(HOOKEXEC, querie, args, nil), func(querie string, args []interface{}) (int64, error) {
+		var err error
+		rel, err = p.Tx.ExecContext(p.ctx, querie, args...)
+		return rowsAffected(rel, err)
+	})
+	return rel, err
+}
+
+/*ExecDatatable : ejecuta dentro de la transaccion una accion datable esta puede ser INSERT,DELETE,UPDATE,UPSERT*/
+func (p *StTx) ExecDatatable(data DataTable, acc string) error {
+	if data.GetTp() == "" {
+		data.SetTp(p.cnx.Conexion.TP)
+	}
+	queries, err := data.GenSQL(acc)
+	if err != nil {
+		return err
+	}
+	return p.Exec(queries)
+}
+
+/*QueryEach : Ejecuta un query dentro de la transaccion enviando cada fila a la funcion,
+si la funcion regresa un error se detiene la lectura y se regresa el error*/
+func (p *StTx) QueryEach(query StQuery, fn func(StData) error) error {
+	return eachExt(p.ctx, p.Tx, p.cnx.Hooks, query, fn)
+}
@@ -0,0 +1,73 @@
+package migrate
+
+import (
+	"regexp"
+
+	db "github.com/rafael180496/core-util/database"
+)
+
+type (
+	/*StMigration : migracion leida de los archivos numerados .up.sql y .down.sql*/
+	StMigration struct {
+		Version  int64
+		Name     string
+		Up       string
+		Down     string
+		Checksum string
+	}
+	/*StStatus : estado de una migracion comparando los archivos con la tabla de migraciones
+	Dirty : el checksum aplicado no coincide con el archivo actual*/
+	StStatus struct {
+		Version   int64  `json:"version"`
+		Name      string `json:"name"`
+		Applied   bool   `json:"applied"`
+		AppliedAt string `json:"appliedAt"`
+		Checksum  string `json:"checksum"`
+		Dirty     bool   `json:"dirty"`
+	}
+)
+
+const (
+	/*TABLEMIGRATE : nombre por defecto de la tabla de migraciones*/
+	TABLEMIGRATE = "SCHEMA_MIGRATIONS"
+	/*NOSPLIT : marca en la primera linea del archivo para ejecutarlo como una sola sentencia (bloques PL/SQL, triggers)*/
+	NOSPLIT = "-- +nosplit"
+)
+
+var (
+	/*fileFor : formato de los archivos de migracion 0001_nombre.up.sql*/
+	fileFor = regexp.MustCompile(`^(\d+)_([A-Za-z0-9_\-]+)\.(up|down)\.sql$`)
+	/*CREATEMIGRATE : sql de creacion de la tabla de migraciones por tipo de base de datos*/
+	CREATEMIGRATE = map[string]string{
+		db.Ora: `CREATE TABLE %s (
+			VERSION NUMBER(19) NOT NULL PRIMARY KEY,
+			NAME VARCHAR2(255) NOT NULL,
+			CHECKSUM VARCHAR2(64) NOT NULL,
+			APPLIED_AT VARCHAR2(30) NOT NULL
+		)`,
+		db.Post: `CREATE TABLE %s (
+			VERSION BIGINT NOT NULL PRIMARY KEY,
+			NAME VARCHAR(255) NOT NULL,
+			CHECKSUM VARCHAR(64) NOT NULL,
+			APPLIED_AT VARCHAR(30) NOT NULL
+		)`,
+		db.Mysql: `CREATE TABLE %s (
+			VERSION BIGINT NOT NULL PRIMARY KEY,
+			NAME VARCHAR(255) NOT NULL,
+			CHECKSUM VARCHAR(64) NOT NULL,
+			APPLIED_AT VARCHAR(30) NOT NULL
+		)`,
+		db.Sqlser: `CREATE TABLE %s (
+			VERSION BIGINT NOT NULL PRIMARY KEY,
+			NAME VARCHAR(255) NOT NULL,
+			CHECKSUM VARCHAR(64) NOT NULL,
+			APPLIED_AT VARCHAR(30) NOT NULL
+		)`,
+		db.SQLLite: `CREATE TABLE %s (
+			VERSION INTEGER NOT NULL PRIMARY KEY,
+			NAME TEXT NOT NULL,
+			CHECKSUM TEXT NOT NULL,
+			APPLIED_AT TEXT NOT NULL
+		)`,
+	}
+)
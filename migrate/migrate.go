@@ -0,0 +1,381 @@
+package migrate
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	db "github.com/rafael180496/core-util/database"
+	utl "github.com/rafael180496/core-util/utility"
+)
+
+type (
+	/*StMigrate : administra las migraciones versionadas de una conexion,
+	cada migracion se ejecuta en su propia transaccion junto con su registro en la tabla de migraciones.
+	En mysql y oracle los DDL hacen commit implicito por lo que una migracion fallida puede quedar a medias.*/
+	StMigrate struct {
+		Cnx        *db.StConect
+		Table      string
+		migrations []StMigration
+	}
+)
+
+/*NewMigrate : carga las migraciones de un directorio con archivos 0001_nombre.up.sql y 0001_nombre.down.sql*/
+func NewMigrate(cnx *db.StConect, dir string) (*StMigrate, error) {
+	if !utl.FileExist(dir, true) {
+		return nil, fmt.Errorf("the migration directory does not exist")
+	}
+	return NewMigrateFS(cnx, os.DirFS(dir), ".")
+}
+
+/*NewMigrateFS : carga las migraciones de un fs.FS (embed.FS) en el directorio indicado*/
+func NewMigrateFS(cnx *db.StConect, fsys fs.FS, dir string) (*StMigrate, error) {
+	if cnx == nil {
+		return nil, fmt.Errorf("the connection is nil")
+	}
+	migrations, err := readMigrations(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	return &StMigrate{
+		Cnx:        cnx,
+		Table:      TABLEMIGRATE,
+		migrations: migrations,
+	}, nil
+}
+
+/*Migrations : envia las migraciones cargadas ordenadas por version*/
+func (p *StMigrate) Migrations() []StMigration {
+	return p.migrations
+}
+
+/*Up : aplica todas las migraciones pendientes regresando la cantidad aplicada*/
+func (p *StMigrate) Up() (int, error) {
+	if len(p.migrations) <= 0 {
+		return 0, nil
+	}
+	return p.upTo(p.migrations[len(p.migrations)-1].Version)
+}
+
+/*Down : revierte las ultimas n migraciones aplicadas regresando la cantidad revertida*/
+func (p *StMigrate) Down(n int) (int, error) {
+	if n <= 0 {
+		return 0, fmt.Errorf("the number of migrations must be greater than zero")
+	}
+	applied, err := p.applied()
+	if err != nil {
+		return 0, err
+	}
+	versions := sortedVersions(applied)
+	cant := 0
+	for i := len(versions) - 1; i >= 0 && cant < n; i-- {
+		err = p.down(versions[i])
+		if err != nil {
+			return cant, err
+		}
+		cant++
+	}
+	return cant, nil
+}
+
+/*Goto : aplica o revierte las migraciones hasta dejar la base de datos en la version indicada,
+la version 0 revierte todas las migraciones*/
+func (p *StMigrate) Goto(version int64) error {
+	if version < 0 {
+		return fmt.Errorf("invalid migration version")
+	}
+	if version > 0 {
+		_, err := p.find(version)
+		if err != nil {
+			return err
+		}
+	}
+	applied, err := p.applied()
+	if err != nil {
+		return err
+	}
+	versions := sortedVersions(applied)
+	for i := len(versions) - 1; i >= 0 && versions[i] > version; i-- {
+		err = p.down(versions[i])
+		if err != nil {
+			return err
+		}
+	}
+	_, err = p.upTo(version)
+	return err
+}
+
+/*Version : envia la ultima version aplicada, 0 si no hay migraciones aplicadas*/
+func (p *StMigrate) Version() (int64, error) {
+	applied, err := p.applied()
+	if err != nil {
+		return 0, err
+	}
+	versions := sortedVersions(applied)
+	if len(versions) <= 0 {
+		return 0, nil
+	}
+	return versions[len(versions)-1], nil
+}
+
+/*Status : envia el estado de cada migracion de los archivos y las aplicadas que ya no tienen archivo*/
+func (p *StMigrate) Status() ([]StStatus, error) {
+	var result []StStatus
+	applied, err := p.applied()
+	if err != nil {
+		return result, err
+	}
+	for _, item := range p.migrations {
+		status := StStatus{
+			Version: item.Version,
+			Name:    item.Name,
+		}
+		if row, ok := applied[item.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = row.AppliedAt
+			status.Checksum = row.Checksum
+			status.Dirty = row.Checksum != item.Checksum
+			delete(applied, item.Version)
+		}
+		result = append(result, status)
+	}
+	for _, row := range applied {
+		row.Dirty = true
+		result = append(result, row)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Version < result[j].Version
+	})
+	return result, nil
+}
+
+/*upTo : aplica las migraciones pendientes hasta la version indicada validando los checksum aplicados*/
+func (p *StMigrate) upTo(version int64) (int, error) {
+	applied, err := p.applied()
+	if err != nil {
+		return 0, err
+	}
+	cant := 0
+	for _, item := range p.migrations {
+		if item.Version > version {
+			break
+		}
+		if row, ok := applied[item.Version]; ok {
+			if row.Checksum != item.Checksum {
+				return cant, fmt.Errorf("the migration %d was modified after being applied", item.Version)
+			}
+			continue
+		}
+		err = p.up(item)
+		if err != nil {
+			return cant, err
+		}
+		cant++
+	}
+	return cant, nil
+}
+
+/*up : ejecuta una migracion y la registra en la misma transaccion*/
+func (p *StMigrate) up(item StMigration) error {
+	return p.Cnx.WithTx(func(tx *db.StTx) error {
+		err := execScript(tx, item.Up)
+		if err != nil {
+			return fmt.Errorf("migration %d_%s: %s", item.Version, item.Name, err.Error())
+		}
+		return tx.ExecOne(db.StQuery{
+			Querie: fmt.Sprintf(`INSERT INTO %s (VERSION,NAME,CHECKSUM,APPLIED_AT) VALUES (:VERSION,:NAME,:CHECKSUM,:APPLIED_AT)`, p.Table),
+			Args: map[string]interface{}{
+				"VERSION":    item.Version,
+				"NAME":       item.Name,
+				"CHECKSUM":   item.Checksum,
+				"APPLIED_AT": utl.ToDateStr(time.Now()),
+			},
+		})
+	})
+}
+
+/*down : revierte una migracion aplicada y elimina su registro en la misma transaccion*/
+func (p *StMigrate) down(version int64) error {
+	item, err := p.find(version)
+	if err != nil {
+		return err
+	}
+	if !utl.IsNilStr(utl.Trim(item.Down)) {
+		return fmt.Errorf("the migration %d does not have a down file", version)
+	}
+	return p.Cnx.WithTx(func(tx *db.StTx) error {
+		err := execScript(tx, item.Down)
+		if err != nil {
+			return fmt.Errorf("migration %d_%s: %s", item.Version, item.Name, err.Error())
+		}
+		return tx.ExecOne(db.StQuery{
+			Querie: fmt.Sprintf(`DELETE FROM %s WHERE VERSION = :VERSION`, p.Table),
+			Args: map[string]interface{}{
+				"VERSION": version,
+			},
+		})
+	})
+}
+
+/*find : busca una migracion cargada por version*/
+func (p *StMigrate) find(version int64) (StMigration, error) {
+	for _, item := range p.migrations {
+		if item.Version == version {
+			return item, nil
+		}
+	}
+	return StMigration{}, fmt.Errorf("the migration %d does not exist", version)
+}
+
+/*applied : crea la tabla de migraciones si no existe y envia las versiones aplicadas*/
+func (p *StMigrate) applied() (map[int64]StStatus, error) {
+	result := make(map[int64]StStatus)
+	err := p.createTable()
+	if err != nil {
+		return result, err
+	}
+	rows, err := p.Cnx.QueryMap(db.StQuery{
+		Querie:  fmt.Sprintf(`SELECT VERSION, NAME, CHECKSUM, APPLIED_AT FROM %s`, p.Table),
+		Primary: true,
+	}, 0, true, false)
+	if err != nil {
+		return result, err
+	}
+	for _, item := range rows {
+		row := item.UpperKey()
+		version, _ := row.ToInt64("VERSION")
+		name, _ := row.ToString("NAME")
+		checksum, _ := row.ToString("CHECKSUM")
+		appliedAt, _ := row.ToString("APPLIED_AT")
+		result[version] = StStatus{
+			Version:   version,
+			Name:      name,
+			Applied:   true,
+			AppliedAt: appliedAt,
+			Checksum:  checksum,
+		}
+	}
+	return result, nil
+}
+
+/*createTable : crea la tabla de migraciones si no existe*/
+func (p *StMigrate) createTable() error {
+	if p.Cnx.ValidTable(p.Table) {
+		return nil
+	}
+	script, ok := CREATEMIGRATE[p.Cnx.Conexion.TP]
+	if !ok {
+		return fmt.Errorf("type database not supports")
+	}
+	_, err := p.Cnx.ExecNative(fmt.Sprintf(script, p.Table), true)
+	return err
+}
+
+/*execScript : ejecuta cada sentencia del script dentro de la transaccion*/
+func execScript(tx *db.StTx, script string) error {
+	for _, item := range splitSQL(script) {
+		_, err := tx.ExecNative(item)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*splitSQL : separa un script en sentencias terminadas en ; al final de la linea,
+si la primera linea es NOSPLIT se envia el script completo como una sola sentencia*/
+func splitSQL(script string) []string {
+	var (
+		result  []string
+		current []string
+	)
+	script = strings.TrimSpace(strings.ReplaceAll(script, "\r", ""))
+	if strings.HasPrefix(script, NOSPLIT) {
+		return []string{script}
+	}
+	for _, line := range strings.Split(script, "\n") {
+		trim := strings.TrimSpace(line)
+		if trim == "" || strings.HasPrefix(trim, "--") {
+			continue
+		}
+		if strings.HasSuffix(trim, ";") {
+			current = append(current, strings.TrimSuffix(strings.TrimRight(line, " \t"), ";"))
+			result = append(result, strings.Join(current, "\n"))
+			current = nil
+			continue
+		}
+		current = append(current, line)
+	}
+	if len(current) > 0 {
+		result = append(result, strings.Join(current, "\n"))
+	}
+	return result
+}
+
+/*readMigrations : lee y valida los archivos de migracion de un directorio*/
+func readMigrations(fsys fs.FS, dir string) ([]StMigration, error) {
+	var result []StMigration
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return result, err
+	}
+	items := make(map[int64]*StMigration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := fileFor.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		version, err := utl.StrToInt64(match[1])
+		if err != nil || version <= 0 {
+			return result, fmt.Errorf("invalid migration version %s", entry.Name())
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return result, err
+		}
+		item, ok := items[version]
+		if !ok {
+			item = &StMigration{Version: version, Name: match[2]}
+			items[version] = item
+		}
+		if item.Name != match[2] {
+			return result, fmt.Errorf("duplicate migration version %d", version)
+		}
+		switch match[3] {
+		case "up":
+			item.Up = string(data)
+			item.Checksum = utl.GeneredHashSha256(item.Up)
+		case "down":
+			item.Down = string(data)
+		}
+	}
+	for _, item := range items {
+		if !utl.IsNilStr(utl.Trim(item.Up)) {
+			return result, fmt.Errorf("the migration %d does not have an up file", item.Version)
+		}
+		result = append(result, *item)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Version < result[j].Version
+	})
+	return result, nil
+}
+
+/*sortedVersions : ordena las versiones aplicadas de menor a mayor*/
+func sortedVersions(applied map[int64]StStatus) []int64 {
+	var versions []int64
+	for version := range applied {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i] < versions[j]
+	})
+	return versions
+}
@@ -0,0 +1,5 @@
+-- name: findClient
+SELECT ID, NAME FROM CLIENTS WHERE ID = :ID AND ROWNUM = 1
+
+-- name: listClients
+SELECT ID, NAME FROM CLIENTS ORDER BY ID
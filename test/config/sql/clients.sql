@@ -0,0 +1,7 @@
+-- name: findClient
+SELECT ID, NAME
+FROM CLIENTS
+WHERE ID = :ID;
+
+-- name: listClients
+SELECT ID, NAME FROM CLIENTS ORDER BY ID
@@ -0,0 +1,234 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	db "github.com/rafael180496/core-util/database"
+)
+
+/*newSqlite : crea una conexion sqllite temporal con la tabla CLIENTS de prueba*/
+func newSqlite(t *testing.T) db.StConect {
+	t.Helper()
+	cnx := db.StConect{
+		Conexion: db.StCadConect{
+			TP:   db.SQLLite,
+			File: filepath.Join(t.TempDir(), "prueba.db"),
+		},
+	}
+	_, err := cnx.ExecNative(`CREATE TABLE CLIENTS (ID INTEGER PRIMARY KEY, NAME TEXT NOT NULL)`, true)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	t.Cleanup(func() { cnx.Close() })
+	return cnx
+}
+
+/*TestQueryCtx : ejecuta insert y consultas con contexto*/
+func TestQueryCtx(t *testing.T) {
+	cnx := newSqlite(t)
+	ctx := context.Background()
+	err := cnx.ExecCtx(ctx, []db.StQuery{
+		{Querie: `INSERT INTO CLIENTS (ID,NAME) VALUES (:ID,:NAME)`, Args: map[string]interface{}{"ID": 1, "NAME": "pedro"}},
+		{Querie: `INSERT INTO CLIENTS (ID,NAME) VALUES (:ID,:NAME)`, Args: map[string]interface{}{"ID": 2, "NAME": "juan"}},
+	}, true)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	rows, err := cnx.QueryMapCtx(ctx, db.StQuery{Querie: `SELECT * FROM CLIENTS`}, 0, true, false)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if len(rows) != 2 {
+		t.Errorf("Actual ( %d ) does not match expected ( %d )", len(rows), 2)
+	}
+}
+
+/*TestExecCtxCancel : valida que un contexto cancelado no ejecute cambios*/
+func TestExecCtxCancel(t *testing.T) {
+	cnx := newSqlite(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := cnx.ExecCtx(ctx, []db.StQuery{
+		{Querie: `INSERT INTO CLIENTS (ID,NAME) VALUES (:ID,:NAME)`, Args: map[string]interface{}{"ID": 1, "NAME": "pedro"}},
+	}, true)
+	if err == nil {
+		t.Fatalf("expected error with canceled context")
+	}
+	rows, err := cnx.QueryMap(db.StQuery{Querie: `SELECT * FROM CLIENTS`}, 0, true, false)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if len(rows) != 0 {
+		t.Errorf("Actual ( %d ) does not match expected ( %d )", len(rows), 0)
+	}
+}
+
+/*TestPoolDBX : valida que el pool se conserve en un .dbx y se aplique al conectar*/
+func TestPoolDBX(t *testing.T) {
+	cad := db.StCadConect{
+		TP:          db.SQLLite,
+		File:        filepath.Join(t.TempDir(), "pool.db"),
+		MaxOpen:     4,
+		MaxIdle:     2,
+		MaxLifetime: 300,
+	}
+	data, err := db.CreateDBConect(cad, "abc123")
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	cnx := db.StConect{}
+	cnx.Conexion, err = db.DecripConect(data, "abc123")
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if cnx.Conexion.MaxOpen != 4 || cnx.Conexion.MaxIdle != 2 || cnx.Conexion.MaxLifetime != 300 {
+		t.Fatalf("pool not preserved %#v", cnx.Conexion)
+	}
+	err = cnx.Con()
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	defer cnx.Close()
+	if max := cnx.DBGO.Stats().MaxOpenConnections; max != 4 {
+		t.Errorf("Actual ( %d ) does not match expected ( %d )", max, 4)
+	}
+}
+
+/*TestWithTx : valida commit, rollback y savepoints en una transaccion*/
+func TestWithTx(t *testing.T) {
+	cnx := newSqlite(t)
+	insert := `INSERT INTO CLIENTS (ID,NAME) VALUES (:ID,:NAME)`
+	err := cnx.WithTx(func(tx *db.StTx) error {
+		err := tx.ExecOne(db.StQuery{Querie: insert, Args: map[string]interface{}{"ID": 1, "NAME": "pedro"}})
+		if err != nil {
+			return err
+		}
+		err = tx.Savepoint("SP1")
+		if err != nil {
+			return err
+		}
+		err = tx.ExecOne(db.StQuery{Querie: insert, Args: map[string]interface{}{"ID": 2, "NAME": "juan"}})
+		if err != nil {
+			return err
+		}
+		return tx.RollbackTo("SP1")
+	})
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	err = cnx.WithTx(func(tx *db.StTx) error {
+		err := tx.ExecOne(db.StQuery{Querie: insert, Args: map[string]interface{}{"ID": 3, "NAME": "ana"}})
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("forced error")
+	})
+	if err == nil {
+		t.Fatalf("expected error from transaction")
+	}
+	rows, err := cnx.QueryMap(db.StQuery{Querie: `SELECT * FROM CLIENTS`}, 0, true, false)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if len(rows) != 1 {
+		t.Errorf("Actual ( %d ) does not match expected ( %d )", len(rows), 1)
+	}
+}
+
+/*insertClients : agrega n clientes de prueba*/
+func insertClients(t *testing.T, cnx *db.StConect, n int) {
+	t.Helper()
+	for i := 1; i <= n; i++ {
+		err := cnx.ExecOne(db.StQuery{
+			Querie: `INSERT INTO CLIENTS (ID,NAME) VALUES (:ID,:NAME)`,
+			Args:   map[string]interface{}{"ID": i, "NAME": fmt.Sprintf("client%d", i)},
+		}, true)
+		if err != nil {
+			t.Fatalf("Error:%s", err.Error())
+		}
+	}
+}
+
+/*TestQueryEach : lee las filas una por una con QueryEach y QueryIter deteniendo la lectura antes del final*/
+func TestQueryEach(t *testing.T) {
+	cnx := newSqlite(t)
+	insertClients(t, &cnx, 5)
+	query := db.StQuery{Querie: `SELECT * FROM CLIENTS ORDER BY ID`}
+	cant := 0
+	err := cnx.QueryEach(query, true, func(data db.StData) error {
+		cant++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if cant != 5 {
+		t.Errorf("Actual ( %d ) does not match expected ( %d )", cant, 5)
+	}
+	stop := fmt.Errorf("stop")
+	cant = 0
+	err = cnx.QueryEach(query, true, func(data db.StData) error {
+		cant++
+		if cant == 2 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || cant != 2 {
+		t.Errorf("the reading did not stop in the second row")
+	}
+	if cnx.DBGO.Ping() != nil {
+		t.Errorf("the stopped reading closed the connection with indConect true")
+	}
+	iter, err := cnx.QueryIter(query, true)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	defer iter.Close()
+	cant = 0
+	for iter.Next() {
+		cant++
+		data := iter.Data()
+		id, _ := data.ToInt64("ID")
+		if id != int64(cant) {
+			t.Errorf("Actual ( %d ) does not match expected ( %d )", id, cant)
+		}
+	}
+	if iter.Err() != nil {
+		t.Fatalf("Error:%s", iter.Err().Error())
+	}
+	if cant != 5 {
+		t.Errorf("Actual ( %d ) does not match expected ( %d )", cant, 5)
+	}
+}
+
+/*TestQueryPage : pagina un query con el conteo total de filas*/
+func TestQueryPage(t *testing.T) {
+	cnx := newSqlite(t)
+	insertClients(t, &cnx, 5)
+	query := db.StQuery{
+		Querie: `SELECT * FROM CLIENTS WHERE ID > :ID ORDER BY ID`,
+		Args:   map[string]interface{}{"ID": 0},
+	}
+	page, err := cnx.QueryPage(query, 2, 2, true, true)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if len(page.Data) != 2 || page.Total != 5 || page.Pages != 3 || !page.Next {
+		t.Errorf("invalid page:%+v", page)
+	}
+	id, _ := page.Data[0].ToInt64("ID")
+	if id != 3 {
+		t.Errorf("Actual ( %d ) does not match expected ( %d )", id, 3)
+	}
+	page, err = cnx.QueryPage(query, 3, 2, false, true)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if len(page.Data) != 1 || page.Total != 0 || page.Next {
+		t.Errorf("invalid page:%+v", page)
+	}
+}
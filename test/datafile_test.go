@@ -0,0 +1,62 @@
+package test
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	db "github.com/rafael180496/core-util/database"
+)
+
+/*TestExportCSV : exporta e importa un datatable en csv con delimitador y mapeo de encabezados*/
+func TestExportCSV(t *testing.T) {
+	data := newTable(t, "clients", newClients(3), "ID")
+	var buf bytes.Buffer
+	err := data.Export(&buf, db.StFileOpt{Format: db.CSV, Delimiter: ';'})
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if !strings.HasPrefix(buf.String(), "ID;NAME\n1;client\n") {
+		t.Errorf("invalid csv:%s", buf.String())
+	}
+	result, err := db.ImportDataTable(strings.NewReader("Codigo,Nombre,Extra\n1,pedro,x\n2,,y\n"), "clients", []string{"ID"}, db.StFileOpt{
+		Format:  db.CSV,
+		Mapping: map[string]string{"Codigo": "ID", "Nombre": "NAME"},
+	})
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	row, _ := result.GetRow(2)
+	if result.LenRows() != 2 || row["ID"] != "2" || row["NAME"] != nil || row.ValidColum("EXTRA") {
+		t.Errorf("invalid rows:%v", result.GetRows())
+	}
+}
+
+/*TestExportQuery : exporta un query a json y ndjson y lo vuelve a cargar en otra tabla*/
+func TestExportQuery(t *testing.T) {
+	cnx := newSqlite(t)
+	insertClients(t, &cnx, 5)
+	query := db.StQuery{Querie: `SELECT ID, NAME FROM CLIENTS ORDER BY ID`}
+	for _, ext := range []string{".json", ".ndjson", ".csv"} {
+		path := filepath.Join(t.TempDir(), "clients"+ext)
+		count, err := cnx.ExportQueryFile(path, query, db.StFileOpt{}, true)
+		if err != nil {
+			t.Fatalf("Error:%s", err.Error())
+		}
+		if count != 5 {
+			t.Errorf("Actual ( %d ) does not match expected ( %d )", count, 5)
+		}
+		data, err := db.ImportDataTableFile(path, "clients", []string{"ID"}, db.StFileOpt{})
+		if err != nil {
+			t.Fatalf("Error:%s", err.Error())
+		}
+		if data.LenRows() != 5 {
+			t.Fatalf("Actual ( %d ) does not match expected ( %d )", data.LenRows(), 5)
+		}
+		err = cnx.ExecDatatable(data, db.UPSERT, true)
+		if err != nil {
+			t.Fatalf("Error:%s", err.Error())
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package test
+
+import (
+	"testing"
+
+	db "github.com/rafael180496/core-util/database"
+)
+
+/*newClients : genera filas de prueba para la tabla CLIENTS*/
+func newClients(cant int) []db.StData {
+	var rows []db.StData
+	for i := 1; i <= cant; i++ {
+		rows = append(rows, db.StData{"ID": i, "NAME": "client"})
+	}
+	return rows
+}
+
+/*newTable : crea un datatable y le agrega los indices despues de las filas*/
+func newTable(t *testing.T, table string, rows []db.StData, index ...string) db.DataTable {
+	t.Helper()
+	data := db.NewDataTable(table, rows, nil)
+	err := data.AddIndexs(index...)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	return data
+}
+
+/*TestGenInsertsBatch : valida que los lotes respeten el limite de parametros de sqllite*/
+func TestGenInsertsBatch(t *testing.T) {
+	data := newTable(t, "clients", newClients(1500), "ID")
+	data.SetTp(db.SQLLite)
+	data.SetBatch(1000)
+	queries, err := data.GenSQL(db.INSERT)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if len(queries) != 4 {
+		t.Errorf("Actual ( %d ) does not match expected ( %d )", len(queries), 4)
+	}
+	for _, item := range queries {
+		if len(item.Args) > 999 {
+			t.Errorf("too many params %d", len(item.Args))
+		}
+	}
+}
+
+/*TestExecDatatableBatch : inserta por lotes en una base sqllite*/
+func TestExecDatatableBatch(t *testing.T) {
+	cnx := newSqlite(t)
+	data := newTable(t, "clients", newClients(1500), "ID")
+	data.SetBatch(500)
+	err := cnx.ExecDatatable(data, db.INSERT, true)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	row, err := cnx.QueryOne(db.StQuery{Querie: `SELECT COUNT(*) AS CANT FROM CLIENTS`}, true)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if cant, _ := row.ToInt("CANT"); cant != 1500 {
+		t.Errorf("Actual ( %d ) does not match expected ( %d )", cant, 1500)
+	}
+}
+
+/*TestGenUpserts : genera el upsert de cada tipo de base de datos*/
+func TestGenUpserts(t *testing.T) {
+	expected := map[string]string{
+		db.Post:    `INSERT INTO CLIENTS (ID,NAME) VALUES(:ID,:NAME) ON CONFLICT (ID) DO UPDATE SET NAME = EXCLUDED.NAME`,
+		db.SQLLite: `INSERT INTO CLIENTS (ID,NAME) VALUES(:ID,:NAME) ON CONFLICT (ID) DO UPDATE SET NAME = EXCLUDED.NAME`,
+		db.Mysql:   `INSERT INTO CLIENTS (ID,NAME) VALUES(:ID,:NAME) ON DUPLICATE KEY UPDATE NAME = VALUES(NAME)`,
+		db.Sqlser:  `MERGE INTO CLIENTS AS TGT USING (SELECT :ID AS ID, :NAME AS NAME) AS SRC ON (TGT.ID = SRC.ID) WHEN MATCHED THEN UPDATE SET TGT.NAME = SRC.NAME WHEN NOT MATCHED THEN INSERT (ID,NAME) VALUES (SRC.ID,SRC.NAME);`,
+		db.Ora:     `MERGE INTO CLIENTS TGT USING (SELECT :ID AS ID, :NAME AS NAME FROM DUAL) SRC ON (TGT.ID = SRC.ID) WHEN MATCHED THEN UPDATE SET TGT.NAME = SRC.NAME WHEN NOT MATCHED THEN INSERT (ID,NAME) VALUES (SRC.ID,SRC.NAME)`,
+	}
+	for tp, sql := range expected {
+		data := newTable(t, "clients", newClients(1), "ID")
+		data.SetTp(tp)
+		queries, err := data.GenSQL(db.UPSERT)
+		if err != nil {
+			t.Fatalf("Error:%s", err.Error())
+		}
+		if len(queries) != 1 || queries[0].Querie != sql {
+			t.Errorf("%s: Actual ( %s ) does not match expected ( %s )", tp, queries[0].Querie, sql)
+		}
+	}
+	keys := newTable(t, "clients", []db.StData{{"ID": 1}}, "ID")
+	keys.SetTp(db.Post)
+	queries, err := keys.GenSQL(db.UPSERT)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if sql := `INSERT INTO CLIENTS (ID) VALUES(:ID) ON CONFLICT (ID) DO NOTHING`; queries[0].Querie != sql {
+		t.Errorf("Actual ( %s ) does not match expected ( %s )", queries[0].Querie, sql)
+	}
+}
+
+/*TestExecDatatableUpsert : inserta y actualiza por llave en una base sqllite*/
+func TestExecDatatableUpsert(t *testing.T) {
+	cnx := newSqlite(t)
+	data := newTable(t, "clients", newClients(2), "ID")
+	err := cnx.ExecDatatable(data, db.UPSERT, true)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	data = newTable(t, "clients", []db.StData{{"ID": 2, "NAME": "updated"}, {"ID": 3, "NAME": "new"}}, "ID")
+	err = cnx.ExecDatatable(data, db.UPSERT, true)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	rows, err := cnx.QueryMap(db.StQuery{Querie: `SELECT * FROM CLIENTS ORDER BY ID`}, 0, true, false)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if len(rows) != 3 {
+		t.Fatalf("Actual ( %d ) does not match expected ( %d )", len(rows), 3)
+	}
+	if name, _ := rows[1].ToString("NAME"); name != "updated" {
+		t.Errorf("Actual ( %s ) does not match expected ( %s )", name, "updated")
+	}
+}
+
+/*client : struct de prueba para los datatable de structs*/
+type client struct {
+	ID    int     `db:"id,pk"`
+	Name  string  `db:"name"`
+	Email *string `db:"email,omitempty"`
+	Temp  string  `db:"-"`
+}
+
+/*TestDataTableFromStructs : genera el mismo sql que un datatable de maps*/
+func TestDataTableFromStructs(t *testing.T) {
+	var clients []client
+	for _, item := range newClients(3) {
+		clients = append(clients, client{ID: item["ID"].(int), Name: item["NAME"].(string), Temp: "x"})
+	}
+	data, err := db.NewDataTableFromStructs("clients", clients)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	expected := newTable(t, "clients", newClients(3), "ID")
+	for _, acc := range []string{db.INSERT, db.UPDATE, db.DELETE} {
+		queries, err := data.GenSQL(acc)
+		if err != nil {
+			t.Fatalf("Error:%s", err.Error())
+		}
+		queriesExp, _ := expected.GenSQL(acc)
+		if len(queries) != len(queriesExp) || queries[0].Querie != queriesExp[0].Querie {
+			t.Errorf("Actual ( %s ) does not match expected ( %s )", queries[0].Querie, queriesExp[0].Querie)
+		}
+	}
+	cnx := newSqlite(t)
+	err = db.ExecDatatableStructs(&cnx, "clients", []*client{{ID: 1, Name: "pedro"}, {ID: 2, Name: "juan"}}, db.INSERT, true)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	err = db.ExecDatatableStructs(&cnx, "clients", []client{{ID: 2, Name: "maria"}}, db.UPDATE, true)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	var result []client
+	err = cnx.QueryStruct(&result, db.StQuery{Querie: `SELECT ID AS id, NAME AS name FROM CLIENTS ORDER BY ID`}, true)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if len(result) != 2 || result[1].Name != "maria" {
+		t.Errorf("invalid rows:%+v", result)
+	}
+}
+
+/*TestDataTableSchema : convierte los valores con el esquema y reporta las filas invalidas antes de ejecutar*/
+func TestDataTableSchema(t *testing.T) {
+	cnx := newSqlite(t)
+	schema, err := cnx.DescribeTable("CLIENTS", true)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	schema[1].Length = 5
+	data := newTable(t, "clients", []db.StData{
+		{"ID": "1", "NAME": "pedro"},
+		{"ID": "x", "NAME": "juan"},
+		{"ID": 3, "NAME": nil},
+		{"ID": 4.5, "NAME": "mariana"},
+	}, "ID")
+	data.SetSchema(schema...)
+	row, _ := data.GetRow(1)
+	if _, ok := row["ID"].(int); !ok {
+		t.Errorf("the value was not converted:%T", row["ID"])
+	}
+	violations := data.ValidRows()
+	if len(violations) != 4 {
+		t.Fatalf("Actual ( %d ) does not match expected ( %d ):%v", len(violations), 4, violations)
+	}
+	for i, item := range []db.StViolation{
+		{Row: 2, Column: "ID"},
+		{Row: 3, Column: "NAME"},
+		{Row: 4, Column: "ID"},
+		{Row: 4, Column: "NAME"},
+	} {
+		if violations[i].Row != item.Row || violations[i].Column != item.Column {
+			t.Errorf("Actual ( %s ) does not match expected row %d column %s", violations[i].Error(), item.Row, item.Column)
+		}
+	}
+	err = cnx.ExecDatatable(data, db.INSERT, true)
+	if err == nil {
+		t.Fatalf("expected validation error")
+	}
+	row, err = cnx.QueryOne(db.StQuery{Querie: `SELECT COUNT(*) AS CANT FROM CLIENTS`}, true)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if cant, _ := row.ToInt("CANT"); cant != 0 {
+		t.Errorf("Actual ( %d ) does not match expected ( %d )", cant, 0)
+	}
+}
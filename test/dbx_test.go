@@ -0,0 +1,73 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	db "github.com/rafael180496/core-util/database"
+)
+
+/*TestRekeyDbFile : crea un .dbx, lo lee, cambia la clave y valida que la clave anterior ya no funcione*/
+func TestRekeyDbFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cnx.dbx")
+	cad := db.StCadConect{
+		TP:   db.Post,
+		Host: "localhost",
+		Port: 5432,
+		User: "admin",
+		Pass: "secret",
+		Name: "sales",
+	}
+	err := db.WriteDbFile(cad, "key-old", path)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Actual ( %v ) does not match expected ( -rw------- )", info.Mode().Perm())
+	}
+	read, err := db.ReadDbFile(path, "key-old")
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if read.Name != cad.Name || read.Pass != cad.Pass {
+		t.Errorf("Actual ( %s %s ) does not match expected ( %s %s )", read.Name, read.Pass, cad.Name, cad.Pass)
+	}
+	err = db.RekeyDbFile(path, "key-old", "key-new")
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	_, err = db.ReadDbFile(path, "key-old")
+	if err == nil {
+		t.Errorf("the old key still decrypts the file")
+	}
+	read, err = db.ReadDbFile(path, "key-new")
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	masked := read.Masked()
+	if masked.Pass != db.REDACTMASK || read.Pass != cad.Pass {
+		t.Errorf("Actual ( %s %s ) does not match expected ( %s %s )", masked.Pass, read.Pass, db.REDACTMASK, cad.Pass)
+	}
+}
+
+/*TestWriteDbFileRename : si no se puede reemplazar el archivo no queda el temporal*/
+func TestWriteDbFileRename(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cnx.dbx")
+	err := os.MkdirAll(filepath.Join(path, "dir"), 0700)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	err = db.WriteDbFile(db.StCadConect{TP: db.SQLLite, File: "prueba.db"}, "key", path)
+	if err == nil {
+		t.Errorf("expected error replacing a directory")
+	}
+	if _, err = os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("the temporary file was not removed")
+	}
+}
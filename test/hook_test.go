@@ -0,0 +1,151 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	db "github.com/rafael180496/core-util/database"
+	utl "github.com/rafael180496/core-util/utility"
+)
+
+/*recordHook : hook de prueba que guarda las sentencias, cancela los DELETE y reescribe la tabla ALIAS*/
+type recordHook struct {
+	stmts []db.StStmt
+}
+
+func (p *recordHook) Before(ctx context.Context, stmt *db.StStmt) error {
+	if strings.HasPrefix(stmt.SQL, db.DELETE) {
+		return fmt.Errorf("delete is not allowed")
+	}
+	stmt.SQL = strings.ReplaceAll(stmt.SQL, "ALIAS", "CLIENTS")
+	return nil
+}
+
+func (p *recordHook) After(ctx context.Context, stmt *db.StStmt) {
+	p.stmts = append(p.stmts, *stmt)
+}
+
+/*TestHooks : los hooks reciben, reescriben y cancelan las sentencias*/
+func TestHooks(t *testing.T) {
+	cnx := newSqlite(t)
+	hook := &recordHook{}
+	cnx.AddHook(hook)
+	insertClients(t, &cnx, 3)
+	rows, err := cnx.QueryMap(db.StQuery{
+		Querie: `SELECT * FROM ALIAS WHERE ID IN (:IDS)`,
+		Args:   map[string]interface{}{"IDS": []int{1, 2}},
+	}, 0, true, false)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if len(rows) != 2 {
+		t.Errorf("Actual ( %d ) does not match expected ( %d )", len(rows), 2)
+	}
+	last := hook.stmts[len(hook.stmts)-1]
+	if last.Kind != db.HOOKQUERY || last.Rows != 2 || len(last.Args) != 2 || !strings.Contains(last.SQL, "FROM CLIENTS") {
+		t.Errorf("Actual ( %+v ) does not match expected", last)
+	}
+	err = cnx.ExecOne(db.StQuery{Querie: `UPDATE CLIENTS SET NAME = 'x' WHERE ID > :ID`, Args: map[string]interface{}{"ID": 1}}, true)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	last = hook.stmts[len(hook.stmts)-1]
+	if last.Kind != db.HOOKEXEC || last.Rows != 2 {
+		t.Errorf("Actual ( %+v ) does not match expected", last)
+	}
+	err = cnx.ExecOne(db.StQuery{Querie: `DELETE FROM CLIENTS`}, true)
+	if err == nil {
+		t.Errorf("expected error with a vetoed statement")
+	}
+	iter, err := cnx.QueryIter(db.StQuery{Querie: `SELECT * FROM CLIENTS`}, true)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	for iter.Next() {
+	}
+	iter.Close()
+	last = hook.stmts[len(hook.stmts)-1]
+	if last.Rows != 3 {
+		t.Errorf("Actual ( %d ) does not match expected ( %d )", last.Rows, 3)
+	}
+}
+
+/*countHook : hook de prueba que cuenta las llamadas de Before y After*/
+type countHook struct {
+	before int
+	after  int
+}
+
+func (p *countHook) Before(ctx context.Context, stmt *db.StStmt) error {
+	p.before++
+	return nil
+}
+
+func (p *countHook) After(ctx context.Context, stmt *db.StStmt) {
+	p.after++
+}
+
+/*TestHooksBackupSavepoint : el script backup y los savepoints pasan por los hooks*/
+func TestHooksBackupSavepoint(t *testing.T) {
+	cnx := newSqlite(t)
+	hook := &countHook{}
+	cnx.AddHook(hook)
+	cnx.SetBackupScript(`CREATE TABLE ORDERS (ID INTEGER PRIMARY KEY)`)
+	err := cnx.ExecBackup()
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if hook.before != 1 || hook.after != 1 {
+		t.Errorf("backup: Actual ( %d %d ) does not match expected ( 1 1 )", hook.before, hook.after)
+	}
+	hook.before, hook.after = 0, 0
+	err = cnx.WithTx(func(tx *db.StTx) error {
+		err := tx.Savepoint("SP1")
+		if err != nil {
+			return err
+		}
+		err = tx.RollbackTo("SP1")
+		if err != nil {
+			return err
+		}
+		return tx.Release("SP1")
+	})
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if hook.before != 3 || hook.after != 3 {
+		t.Errorf("savepoint: Actual ( %d %d ) does not match expected ( 3 3 )", hook.before, hook.after)
+	}
+}
+
+/*TestSlowLog : escribe las sentencias lentas en el log ocultando los argumentos*/
+func TestSlowLog(t *testing.T) {
+	dir := t.TempDir()
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	cnx := newSqlite(t)
+	cnx.AddHook(db.NewRedact("NAME"), db.NewSlowLog(&utl.StLog{Dir: dir, Name: "slow", Prefix: "SLOW "}, 0))
+	err := cnx.ExecOne(db.StQuery{
+		Querie: `INSERT INTO CLIENTS (ID,NAME) VALUES (:ID,:NAME)`,
+		Args:   map[string]interface{}{"ID": 7, "NAME": "secreto"},
+	}, true)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "slow*.log"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("the log was not written:%v", err)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	text := string(data)
+	if !strings.Contains(text, "INSERT INTO CLIENTS") || !strings.Contains(text, db.REDACTMASK) || strings.Contains(text, "secreto") {
+		t.Errorf("Actual ( %s ) does not match expected", text)
+	}
+}
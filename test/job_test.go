@@ -0,0 +1,131 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	db "github.com/rafael180496/core-util/database"
+)
+
+/*writeFile : escribe un archivo de prueba en dir*/
+func writeFile(t *testing.T, dir, name, data string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	err := os.WriteFile(path, []byte(data), 0666)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	return path
+}
+
+/*countClients : cuenta las filas de la tabla CLIENTS*/
+func countClients(t *testing.T, cnx db.StConect) int {
+	t.Helper()
+	row, err := cnx.QueryOne(db.StQuery{Querie: `SELECT COUNT(*) AS TOTAL FROM CLIENTS`}, true)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	total, _ := row.ToInt("TOTAL")
+	return total
+}
+
+/*TestRunJob : ejecuta jobs json e ini con conexiones .dbx y json*/
+func TestRunJob(t *testing.T) {
+	dir := t.TempDir()
+	cnxIn, cnxOut := newSqlite(t), newSqlite(t)
+	insertClients(t, &cnxIn, 3)
+	err := db.CreateDbFile(cnxIn.Conexion, "clave", dir, "in")
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	out := writeFile(t, dir, "out.json", fmt.Sprintf(`{"tp":"SQLLITE","filedb":%q}`, cnxOut.Conexion.File))
+	t.Setenv(db.DBXPASS, "clave")
+	reports := filepath.Join(dir, "reports")
+	path := writeFile(t, dir, "job.json", fmt.Sprintf(`{
+		"name": "clients",
+		"in": %q,
+		"out": %q,
+		"mode": "parallel",
+		"report": %q,
+		"delout": ["DELETE FROM CLIENTS"],
+		"extractors": [
+			{"SQLIn": {"querie": "SELECT * FROM CLIENTS"}, "TableNameOut": "CLIENTS", "Index": ["ID"]}
+		]
+	}`, filepath.Join(dir, "in.dbx"), out, reports))
+	report, err := db.RunJobFile(context.Background(), path, "")
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if report.Status != db.JOBOK || len(report.Tables) != 1 || report.Tables[0].Written != 3 {
+		t.Errorf("Actual ( %+v ) does not match expected", report)
+	}
+	files, err := os.ReadDir(reports)
+	if err != nil || len(files) != 1 {
+		t.Errorf("the report was not written:%v", err)
+	}
+	if total := countClients(t, cnxOut); total != 3 {
+		t.Errorf("Actual ( %d ) does not match expected ( %d )", total, 3)
+	}
+	path = writeFile(t, dir, "job.ini", fmt.Sprintf(`[job]
+name = clients
+in = %s
+out = %s
+
+[delout]
+clients = DELETE FROM CLIENTS
+
+[ext.clients]
+sqlin = SELECT * FROM CLIENTS WHERE ID > 1
+table = CLIENTS
+index = ID
+`, filepath.Join(dir, "in.dbx"), out))
+	report, err = db.RunJobFile(context.Background(), path, "clave")
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if report.Mode != db.JOBPROCESS || len(report.Tables) != 1 || report.Tables[0].Written != 2 {
+		t.Errorf("Actual ( %+v ) does not match expected", report)
+	}
+	if total := countClients(t, cnxOut); total != 2 {
+		t.Errorf("Actual ( %d ) does not match expected ( %d )", total, 2)
+	}
+	report, err = db.RunJobFile(context.Background(), path, "invalida")
+	if err == nil || report.Status != db.JOBERROR {
+		t.Errorf("expected error with an invalid key")
+	}
+}
+
+/*TestLoadJobsWorker : crea los jobs de un MasterWorker desde su configuracion*/
+func TestLoadJobsWorker(t *testing.T) {
+	dir := t.TempDir()
+	cnxIn, cnxOut := newSqlite(t), newSqlite(t)
+	insertClients(t, &cnxIn, 2)
+	in := writeFile(t, dir, "in.json", fmt.Sprintf(`{"tp":"SQLLITE","filedb":%q}`, cnxIn.Conexion.File))
+	out := writeFile(t, dir, "out.json", fmt.Sprintf(`{"tp":"SQLLITE","filedb":%q}`, cnxOut.Conexion.File))
+	job := writeFile(t, dir, "job.json", fmt.Sprintf(`{"name":"clients","in":%q,"out":%q,
+		"extractors":[{"SQLIn":{"querie":"SELECT * FROM CLIENTS"},"TableNameOut":"CLIENTS"}]}`, in, out))
+	config := writeFile(t, dir, "config.json", fmt.Sprintf(`{"workers":[
+		{"type":"M","key":"clients","format":"1","job":%q},
+		{"type":"M","key":"other","format":"1"}
+	]}`, job))
+	jobs, err := db.LoadJobsWorker(config, "")
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if _, ok := jobs["clients"]; !ok || len(jobs) != 1 {
+		t.Fatalf("Actual ( %d ) does not match expected ( %d )", len(jobs), 1)
+	}
+	valid, errs := make(chan bool), make(chan error)
+	go jobs["clients"](valid, errs)
+	select {
+	case <-valid:
+	case err := <-errs:
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if total := countClients(t, cnxOut); total != 2 {
+		t.Errorf("Actual ( %d ) does not match expected ( %d )", total, 2)
+	}
+}
@@ -0,0 +1,43 @@
+package test
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	db "github.com/rafael180496/core-util/database"
+)
+
+/*TestLoadQueries : carga queries nombrados escogiendo la variante por tipo de base de datos*/
+func TestLoadQueries(t *testing.T) {
+	cnx := db.StConect{Conexion: db.StCadConect{TP: db.SQLLite}}
+	err := cnx.LoadQueries("config/sql")
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if len(cnx.Queries) != 2 || strings.Contains(cnx.Queries["findClient"], "ROWNUM") {
+		t.Fatalf("invalid queries %v", cnx.Queries)
+	}
+	ora := db.StConect{Conexion: db.StCadConect{TP: db.Ora}}
+	err = ora.LoadQueries("config/sql")
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if !strings.Contains(ora.Queries["findClient"], "ROWNUM") {
+		t.Fatalf("invalid queries %v", ora.Queries)
+	}
+	err = cnx.LoadQueries("config/sql/clients.sql")
+	if err == nil {
+		t.Fatalf("expected duplicate query error")
+	}
+	fsys := fstest.MapFS{
+		"sql/users.sql": {Data: []byte("-- name: findUser\nSELECT * FROM USERS WHERE USERNAME = :USERNAME")},
+	}
+	err = cnx.LoadQueriesFS(fsys, "sql")
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if _, ok := cnx.Queries["findUser"]; !ok {
+		t.Fatalf("invalid queries %v", cnx.Queries)
+	}
+}
@@ -0,0 +1,75 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	db "github.com/rafael180496/core-util/database"
+	"github.com/rafael180496/core-util/migrate"
+)
+
+/*newMigrations : crea un directorio temporal con migraciones de prueba*/
+func newMigrations(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"0001_create_orders.up.sql":   "CREATE TABLE ORDERS (ID INTEGER PRIMARY KEY, TOTAL REAL);",
+		"0001_create_orders.down.sql": "DROP TABLE ORDERS;",
+		"0002_add_items.up.sql":       "CREATE TABLE ITEMS (ID INTEGER PRIMARY KEY);\nINSERT INTO ITEMS (ID) VALUES (1);",
+		"0002_add_items.down.sql":     "DROP TABLE ITEMS;",
+	}
+	for name, data := range files {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0666)
+		if err != nil {
+			t.Fatalf("Error:%s", err.Error())
+		}
+	}
+	return dir
+}
+
+/*TestMigrate : aplica, revierte y consulta el estado de las migraciones en sqllite*/
+func TestMigrate(t *testing.T) {
+	cnx := newSqlite(t)
+	mig, err := migrate.NewMigrate(&cnx, newMigrations(t))
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	cant, err := mig.Up()
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if cant != 2 || !cnx.ValidTable("ITEMS") {
+		t.Fatalf("Actual ( %d ) does not match expected ( %d )", cant, 2)
+	}
+	cant, err = mig.Down(1)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if cant != 1 || cnx.ValidTable("ITEMS") {
+		t.Fatalf("Actual ( %d ) does not match expected ( %d )", cant, 1)
+	}
+	status, err := mig.Status()
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if len(status) != 2 || !status[0].Applied || status[1].Applied {
+		t.Fatalf("invalid status %#v", status)
+	}
+	err = mig.Goto(0)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	version, err := mig.Version()
+	if err != nil || version != 0 || cnx.ValidTable("ORDERS") {
+		t.Fatalf("Actual ( %d ) does not match expected ( %d )", version, 0)
+	}
+	err = mig.Goto(2)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	rows, err := cnx.QueryMap(db.StQuery{Querie: `SELECT * FROM ITEMS`}, 0, true, false)
+	if err != nil || len(rows) != 1 {
+		t.Fatalf("invalid items %v", err)
+	}
+}
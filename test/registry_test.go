@@ -0,0 +1,158 @@
+package test
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	db "github.com/rafael180496/core-util/database"
+)
+
+/*TestConnRegistry : carga conexiones con nombre de ini, json y un directorio de .dbx*/
+func TestConnRegistry(t *testing.T) {
+	dir := t.TempDir()
+	sales, erp := newSqlite(t), newSqlite(t)
+	insertClients(t, &sales, 2)
+	path := writeFile(t, dir, "cnx.ini", fmt.Sprintf(`[database.sales]
+tp = SQLLITE
+filedb = %s
+
+[database.ERP]
+tp = SQLLITE
+filedb = %s
+
+[other]
+name = x
+`, sales.Conexion.File, erp.Conexion.File))
+	registry, err := db.LoadRegistry(path, "")
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	names := registry.Names()
+	if fmt.Sprint(names) != "[erp sales]" {
+		t.Errorf("Actual ( %v ) does not match expected ( [erp sales] )", names)
+	}
+	cnx, err := registry.Get("SALES")
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	again, _ := registry.Get("sales")
+	if cnx != again {
+		t.Errorf("the connection is not shared")
+	}
+	if total := countClients(t, *cnx); total != 2 {
+		t.Errorf("Actual ( %d ) does not match expected ( %d )", total, 2)
+	}
+	_, err = registry.Get("crm")
+	if err == nil {
+		t.Errorf("expected error with a missing connection")
+	}
+	err = registry.CloseAll()
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if err = cnx.DBGO.Ping(); err == nil {
+		t.Errorf("the connection was not closed")
+	}
+	path = writeFile(t, dir, "cnx.json", fmt.Sprintf(`{"erp":{"tp":"SQLLITE","filedb":%q}}`, erp.Conexion.File))
+	registry, err = db.LoadRegistry(path, "")
+	if err != nil || len(registry.Names()) != 1 {
+		t.Fatalf("Error:%v", err)
+	}
+	dbx := filepath.Join(dir, "dbx")
+	writeFile(t, dir, "readme.txt", "")
+	err = db.CreateDbFile(sales.Conexion, "clave", dir, "sales")
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	_, err = db.LoadRegistry(dbx, "clave")
+	if err == nil {
+		t.Errorf("expected error with a missing directory")
+	}
+	registry, err = db.LoadRegistry(dir, "clave")
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	cnx, err = registry.Get("sales")
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if total := countClients(t, *cnx); total != 2 {
+		t.Errorf("Actual ( %d ) does not match expected ( %d )", total, 2)
+	}
+	registry.CloseAll()
+}
+
+/*TestConnRegistryParallel : consultas en paralelo sobre la misma conexion del registro con una sentencia que falla,
+el error no debe cerrar el pool de los demas (ejecutar con go test -race)*/
+func TestConnRegistryParallel(t *testing.T) {
+	sales := newSqlite(t)
+	insertClients(t, &sales, 3)
+	registry := db.NewConnRegistry()
+	err := registry.Add("sales", sales.Conexion)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	defer registry.CloseAll()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				cnx, err := registry.Get("sales")
+				if err != nil {
+					t.Errorf("Error:%s", err.Error())
+					return
+				}
+				if i == 0 {
+					_, err = cnx.QueryMap(db.StQuery{Querie: `SELECT * FROM NOTEXIST`}, 0, false, false)
+					if err == nil {
+						t.Errorf("expected error with a table that does not exist")
+					}
+					continue
+				}
+				rows, err := cnx.QueryMap(db.StQuery{Querie: `SELECT * FROM CLIENTS`}, 0, false, false)
+				if err != nil || len(rows) != 3 {
+					t.Errorf("Actual ( %d %v ) does not match expected ( 3 <nil> )", len(rows), err)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	cnx, _ := registry.Get("sales")
+	if err = cnx.DBGO.Ping(); err != nil {
+		t.Errorf("the shared connection was closed:%s", err.Error())
+	}
+}
+
+/*TestConnRegistrySlowGet : abrir una conexion lenta no bloquea el Get de los demas nombres*/
+func TestConnRegistrySlowGet(t *testing.T) {
+	registerSlow()
+	sales := newSqlite(t)
+	registry := db.NewConnRegistry()
+	defer registry.CloseAll()
+	err := registry.Add("sales", sales.Conexion)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	err = registry.Add("down", db.StCadConect{TP: "SLOWDB", Host: "localhost", Port: 1, User: "u", Pass: "p", Name: "down", File: "down"})
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	done := make(chan error)
+	go func() {
+		_, err := registry.Get("down")
+		done <- err
+	}()
+	<-slow.started
+	_, err = registry.Get("sales")
+	if err != nil {
+		t.Errorf("Error:%s", err.Error())
+	}
+	slow.release <- struct{}{}
+	if err = <-done; err == nil {
+		t.Errorf("expected error with a connection that is down")
+	}
+}
@@ -0,0 +1,140 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	db "github.com/rafael180496/core-util/database"
+)
+
+/*slowDriver : driver de prueba que avisa en started al conectar y falla como una replica caida
+hasta que se envia release*/
+type slowDriver struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (p slowDriver) Open(name string) (driver.Conn, error) {
+	p.started <- struct{}{}
+	<-p.release
+	return nil, errors.New("the replica is down")
+}
+
+/*slowDialect : dialecto de prueba del driver lento*/
+type slowDialect struct{}
+
+func (slowDialect) Name() string                    { return "SLOWDB" }
+func (slowDialect) Driver() string                  { return "slowdb" }
+func (slowDialect) DSN(conn db.StCadConect) string  { return conn.File }
+func (slowDialect) PingQuery() string               { return `SELECT 1` }
+func (slowDialect) BindType() int                   { return sqlx.QUESTION }
+func (slowDialect) Quote(ident string) string       { return `"` + ident + `"` }
+func (slowDialect) MaxParams() int                  { return 999 }
+func (slowDialect) TableExistsQuery() string        { return db.TESTTABLE[db.SQLLite] }
+func (slowDialect) Limit(q string, l, o int) string { return q }
+
+var (
+	slowOnce sync.Once
+	slow     = slowDriver{started: make(chan struct{}), release: make(chan struct{})}
+)
+
+/*registerSlow : registra una sola vez el driver y el dialecto SLOWDB*/
+func registerSlow() {
+	slowOnce.Do(func() {
+		sql.Register("slowdb", slow)
+		db.RegisterDialect(slowDialect{})
+	})
+}
+
+/*TestReplicas : las consultas se leen de las replicas sanas y las ejecuciones van a la base principal*/
+func TestReplicas(t *testing.T) {
+	primary, replica := newSqlite(t), newSqlite(t)
+	insertClients(t, &replica, 3)
+	cnx := db.StConect{Conexion: primary.Conexion}
+	cnx.Conexion.Replicas = []db.StCadConect{
+		{File: filepath.Join(t.TempDir(), "missing", "replica.db")},
+		{File: replica.Conexion.File},
+	}
+	t.Cleanup(func() { cnx.Close() })
+	insertClients(t, &cnx, 2)
+	cnx.CheckReplicas()
+	if total := countClients(t, cnx); total != 3 {
+		t.Errorf("replica: Actual ( %d ) does not match expected ( %d )", total, 3)
+	}
+	if total := countClients(t, cnx); total != 3 {
+		t.Errorf("round robin: Actual ( %d ) does not match expected ( %d )", total, 3)
+	}
+	status := cnx.ReplicaStatus()
+	if len(status) != 2 || status[0].Healthy || !status[1].Healthy {
+		t.Errorf("Actual ( %+v ) does not match expected", status)
+	}
+	row, err := cnx.QueryOne(db.StQuery{Querie: `SELECT COUNT(*) AS TOTAL FROM CLIENTS`, Primary: true}, false)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if total, _ := row.ToInt("TOTAL"); total != 2 {
+		t.Errorf("primary: Actual ( %d ) does not match expected ( %d )", total, 2)
+	}
+	if status = cnx.ReplicaStatus(); !status[1].Healthy {
+		t.Errorf("closing the primary after a query closed the replicas")
+	}
+	var rows []struct {
+		ID int `db:"ID"`
+	}
+	err = cnx.QueryStructCtx(db.WithPrimary(context.Background()), &rows, db.StQuery{Querie: `SELECT ID FROM CLIENTS`}, true)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if len(rows) != 2 {
+		t.Errorf("context primary: Actual ( %d ) does not match expected ( %d )", len(rows), 2)
+	}
+	_, err = cnx.ExecNative(`CREATE TABLE ORDERS (ID INTEGER PRIMARY KEY)`, true)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if !cnx.ValidTable("ORDERS") {
+		t.Errorf("the table created in the primary was read from a replica")
+	}
+	cnx.Close()
+	if status = cnx.ReplicaStatus(); status[1].Healthy {
+		t.Errorf("Close did not close the replicas")
+	}
+	alone := db.StConect{Conexion: primary.Conexion}
+	alone.Conexion.Replicas = []db.StCadConect{{File: filepath.Join(t.TempDir(), "missing", "replica.db")}}
+	t.Cleanup(func() { alone.Close() })
+	if total := countClients(t, alone); total != 2 {
+		t.Errorf("without healthy replicas: Actual ( %d ) does not match expected ( %d )", total, 2)
+	}
+}
+
+/*TestReplicaSlowCheck : la revision de una replica caida se hace en segundo plano sin bloquear las consultas*/
+func TestReplicaSlowCheck(t *testing.T) {
+	registerSlow()
+	primary := newSqlite(t)
+	insertClients(t, &primary, 2)
+	cnx := db.StConect{Conexion: primary.Conexion}
+	cnx.Conexion.Replicas = []db.StCadConect{{TP: "SLOWDB", File: "down"}}
+	t.Cleanup(func() { cnx.Close() })
+	err := cnx.Con()
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if total := countClients(t, cnx); total != 2 {
+		t.Errorf("Actual ( %d ) does not match expected ( %d )", total, 2)
+	}
+	<-slow.started
+	if total := countClients(t, cnx); total != 2 {
+		t.Errorf("Actual ( %d ) does not match expected ( %d )", total, 2)
+	}
+	slow.release <- struct{}{}
+	cnx.CheckReplicas()
+	if status := cnx.ReplicaStatus(); len(status) != 1 || status[0].Healthy || status[0].Checked.IsZero() {
+		t.Errorf("Actual ( %+v ) does not match expected", status)
+	}
+}
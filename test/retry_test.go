@@ -0,0 +1,154 @@
+package test
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	mssql "github.com/denisenkom/go-mssqldb"
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+	db "github.com/rafael180496/core-util/database"
+)
+
+/*failHook : hook de prueba que falla las sentencias despues de skip con err o una conexion invalida*/
+type failHook struct {
+	skip  int
+	fails int
+	calls int
+	err   error
+}
+
+func (p *failHook) Before(ctx context.Context, stmt *db.StStmt) error {
+	p.calls++
+	if p.calls > p.skip && p.calls <= p.skip+p.fails {
+		if p.err != nil {
+			return p.err
+		}
+		return driver.ErrBadConn
+	}
+	return nil
+}
+
+func (p *failHook) After(ctx context.Context, stmt *db.StStmt) {}
+
+/*TestIsTransient : clasifica los errores transitorios por dialecto*/
+func TestIsTransient(t *testing.T) {
+	cases := []struct {
+		tp       string
+		err      error
+		expected bool
+	}{
+		{db.Post, fmt.Errorf("query: %w", driver.ErrBadConn), true},
+		{db.Post, &pq.Error{Code: "40001"}, true},
+		{db.Post, &pq.Error{Code: "40P01"}, true},
+		{db.Post, &pq.Error{Code: "08006"}, true},
+		{db.Post, &pq.Error{Code: "23505"}, false},
+		{db.Mysql, &mysql.MySQLError{Number: 1213}, true},
+		{db.Mysql, &mysql.MySQLError{Number: 1062}, false},
+		{db.Sqlser, mssql.Error{Number: 1205}, true},
+		{db.Sqlser, mssql.Error{Number: 2627}, false},
+		{db.Ora, errors.New("ORA-00060: deadlock detected while waiting for resource"), true},
+		{db.Ora, errors.New("ORA-00001: unique constraint violated"), false},
+		{db.SQLLite, sqlite3.Error{Code: sqlite3.ErrBusy}, true},
+		{db.SQLLite, context.Canceled, false},
+		{db.SQLLite, errors.New("syntax error"), false},
+	}
+	for _, item := range cases {
+		if db.IsTransient(item.tp, item.err) != item.expected {
+			t.Errorf("%s %v: Actual ( %t ) does not match expected ( %t )", item.tp, item.err, !item.expected, item.expected)
+		}
+	}
+}
+
+/*TestRetry : reintenta las consultas con errores transitorios hasta Retries veces*/
+func TestRetry(t *testing.T) {
+	cnx := newSqlite(t)
+	insertClients(t, &cnx, 2)
+	cnx.Conexion.Retries = 2
+	cnx.Conexion.RetryDelay = 1
+	hook := &failHook{fails: 2}
+	cnx.AddHook(hook)
+	rows, err := cnx.QueryMap(db.StQuery{Querie: `SELECT * FROM CLIENTS`}, 0, true, false)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if len(rows) != 2 || hook.calls != 3 {
+		t.Errorf("Actual ( %d rows %d calls ) does not match expected ( 2 rows 3 calls )", len(rows), hook.calls)
+	}
+	hook.calls, hook.fails = 0, 3
+	err = cnx.ExecOne(db.StQuery{Querie: `DELETE FROM CLIENTS`}, true)
+	if !errors.Is(err, driver.ErrBadConn) || hook.calls != 3 {
+		t.Errorf("Actual ( %v %d calls ) does not match expected ( %v 3 calls )", err, hook.calls, driver.ErrBadConn)
+	}
+}
+
+/*TestRetrySent : una transaccion que ya envio sentencias no se reintenta y se revierte completa*/
+func TestRetrySent(t *testing.T) {
+	cnx := newSqlite(t)
+	cnx.Conexion.Retries = 2
+	cnx.Conexion.RetryDelay = 1
+	hook := &failHook{skip: 1, fails: 1}
+	cnx.AddHook(hook)
+	err := cnx.Exec([]db.StQuery{
+		{Querie: `INSERT INTO CLIENTS (ID, NAME) VALUES (1, 'a')`},
+		{Querie: `INSERT INTO CLIENTS (ID, NAME) VALUES (2, 'b')`},
+	}, true)
+	if !errors.Is(err, driver.ErrBadConn) || hook.calls != 2 {
+		t.Errorf("Actual ( %v %d calls ) does not match expected ( %v 2 calls )", err, hook.calls, driver.ErrBadConn)
+	}
+	if cant := countClients(t, cnx); cant != 0 {
+		t.Errorf("Actual ( %d ) does not match expected ( %d )", cant, 0)
+	}
+}
+
+/*TestRetryConflict : una transaccion que falla por deadlock despues de enviar sentencias se repite completa*/
+func TestRetryConflict(t *testing.T) {
+	cnx := newSqlite(t)
+	cnx.Conexion.Retries = 2
+	cnx.Conexion.RetryDelay = 1
+	hook := &failHook{skip: 1, fails: 1, err: sqlite3.Error{Code: sqlite3.ErrBusy}}
+	cnx.AddHook(hook)
+	err := cnx.Exec([]db.StQuery{
+		{Querie: `INSERT INTO CLIENTS (ID, NAME) VALUES (1, 'a')`},
+		{Querie: `INSERT INTO CLIENTS (ID, NAME) VALUES (2, 'b')`},
+	}, true)
+	if err != nil || hook.calls != 4 {
+		t.Errorf("Actual ( %v %d calls ) does not match expected ( <nil> 4 calls )", err, hook.calls)
+	}
+	if cant := countClients(t, cnx); cant != 2 {
+		t.Errorf("Actual ( %d ) does not match expected ( %d )", cant, 2)
+	}
+	if !db.IsConflict(db.Post, &pq.Error{Code: "40P01"}) || db.IsConflict(db.Post, &pq.Error{Code: "08006"}) {
+		t.Errorf("the postgres deadlock and connection errors are not classified")
+	}
+}
+
+/*TestRetryCancel : la espera de los reintentos de conexion se detiene al cancelar el contexto*/
+func TestRetryCancel(t *testing.T) {
+	cnx := db.StConect{Conexion: db.StCadConect{
+		TP:         db.Post,
+		Host:       "127.0.0.1",
+		Port:       1,
+		User:       "prueba",
+		Pass:       "prueba",
+		Name:       "prueba",
+		Sslmode:    "disable",
+		Retries:    50,
+		RetryDelay: 100,
+	}}
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := cnx.ConCtx(ctx)
+	if err == nil {
+		t.Fatalf("expected error with an invalid port")
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Errorf("the retries did not stop with the context:%s", time.Since(start))
+	}
+}
@@ -0,0 +1,99 @@
+package test
+
+import (
+	"testing"
+
+	db "github.com/rafael180496/core-util/database"
+)
+
+/*TestDescribeTable : lee el catalogo de sqllite con tablas, columnas, llaves e indices*/
+func TestDescribeTable(t *testing.T) {
+	cnx := newSqlite(t)
+	_, err := cnx.ExecNative(`CREATE TABLE ORDERS (
+		CLIENT INTEGER NOT NULL,
+		LINE INTEGER NOT NULL,
+		AMOUNT DECIMAL(10,2),
+		CREATED DATETIME DEFAULT CURRENT_TIMESTAMP,
+		NOTE VARCHAR(50),
+		PRIMARY KEY (CLIENT, LINE)
+	)`, true)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	_, err = cnx.ExecNative(`CREATE UNIQUE INDEX ORDERS_NOTE ON ORDERS (NOTE, CREATED)`, true)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	tables, err := cnx.ListTables(true)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if len(tables) != 2 || tables[0] != "CLIENTS" || tables[1] != "ORDERS" {
+		t.Errorf("invalid tables:%v", tables)
+	}
+	keys, err := cnx.PrimaryKey("orders", true)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if len(keys) != 2 || keys[0] != "CLIENT" || keys[1] != "LINE" {
+		t.Errorf("invalid primary key:%v", keys)
+	}
+	columns, err := cnx.DescribeTable("ORDERS", true)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if cnx.DBGO.Ping() != nil {
+		t.Errorf("the catalog queries closed the connection with indConect true")
+	}
+	expected := []db.StColumn{
+		{Name: "CLIENT", Type: "INTEGER", Tp: db.INTP, Key: true, Position: 1},
+		{Name: "LINE", Type: "INTEGER", Tp: db.INTP, Key: true, Position: 2},
+		{Name: "AMOUNT", Type: "DECIMAL(10,2)", Tp: db.FLTP, Nullable: true, Position: 3},
+		{Name: "CREATED", Type: "DATETIME", Tp: db.DTTP, Nullable: true, Default: "CURRENT_TIMESTAMP", Position: 4},
+		{Name: "NOTE", Type: "VARCHAR(50)", Tp: db.STTP, Nullable: true, Position: 5},
+	}
+	if len(columns) != len(expected) {
+		t.Fatalf("Actual ( %d ) does not match expected ( %d )", len(columns), len(expected))
+	}
+	for i := range expected {
+		if columns[i] != expected[i] {
+			t.Errorf("Actual ( %+v ) does not match expected ( %+v )", columns[i], expected[i])
+		}
+	}
+	indexes, err := cnx.Indexes("ORDERS", true)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	found := false
+	for _, index := range indexes {
+		if index.Name == "ORDERS_NOTE" {
+			found = index.Unique && len(index.Columns) == 2 && index.Columns[0] == "NOTE"
+		}
+	}
+	if !found {
+		t.Errorf("the index ORDERS_NOTE was not found:%+v", indexes)
+	}
+	_, err = cnx.DescribeTable("NOTEXIST", true)
+	if err == nil {
+		t.Errorf("expected error with a table that does not exist")
+	}
+}
+
+/*TestValidExt : completa los indices de los extractores con la llave primaria*/
+func TestValidExt(t *testing.T) {
+	merge := db.StMerge{
+		CnxOut:   newSqlite(t),
+		ItemsExt: []db.StExt{{TableNameOut: "CLIENTS"}},
+	}
+	err := merge.ValidExt()
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if len(merge.ItemsExt[0].Index) != 1 || merge.ItemsExt[0].Index[0] != "ID" {
+		t.Errorf("invalid index:%v", merge.ItemsExt[0].Index)
+	}
+	merge.ItemsExt[0].Index = []string{"CODE"}
+	if merge.ValidExt() == nil {
+		t.Errorf("expected error with a column that does not exist")
+	}
+}
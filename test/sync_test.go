@@ -0,0 +1,369 @@
+package test
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	db "github.com/rafael180496/core-util/database"
+	utl "github.com/rafael180496/core-util/utility"
+)
+
+/*newSyncTable : crea la tabla ITEMS con la columna de marca VERSION*/
+func newSyncTable(t *testing.T, cnx *db.StConect) {
+	t.Helper()
+	_, err := cnx.ExecNative(`CREATE TABLE ITEMS (ID INTEGER PRIMARY KEY, NAME TEXT NOT NULL, VERSION INTEGER NOT NULL)`, true)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+}
+
+/*execItems : agrega o actualiza filas de la tabla ITEMS*/
+func execItems(t *testing.T, cnx *db.StConect, rows ...db.StData) {
+	t.Helper()
+	err := cnx.ExecDatatable(newTable(t, "ITEMS", rows, "ID"), db.UPSERT, true)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+}
+
+/*TestSyncWatermark : sincroniza solo las filas nuevas o modificadas con la marca guardada*/
+func TestSyncWatermark(t *testing.T) {
+	for _, tpState := range []string{"file", "table"} {
+		cnxIn, cnxOut := newSqlite(t), newSqlite(t)
+		newSyncTable(t, &cnxIn)
+		newSyncTable(t, &cnxOut)
+		var state db.StSyncState = db.NewStateFile(filepath.Join(t.TempDir(), "state.json"))
+		if tpState == "table" {
+			state = db.NewStateTable(&cnxOut, "")
+		}
+		merge := db.StMerge{
+			CnxIn:  cnxIn,
+			CnxOut: cnxOut,
+			State:  state,
+			ItemsExt: []db.StExt{{
+				SQLIn:        db.StQuery{Querie: `SELECT ID, NAME, VERSION FROM ITEMS ORDER BY ID`},
+				TableNameOut: "ITEMS",
+				Index:        []string{"ID"},
+				Watermark:    "VERSION",
+			}},
+		}
+		execItems(t, &cnxIn,
+			db.StData{"ID": 1, "NAME": "a", "VERSION": 1},
+			db.StData{"ID": 2, "NAME": "b", "VERSION": 2},
+			db.StData{"ID": 3, "NAME": "c", "VERSION": 3},
+		)
+		for i, expected := range []int{3, 0} {
+			cant, err := merge.SyncExt(merge.ItemsExt[0])
+			if err != nil {
+				t.Fatalf("Error:%s", err.Error())
+			}
+			if cant != expected {
+				t.Errorf("%s sync %d: Actual ( %d ) does not match expected ( %d )", tpState, i, cant, expected)
+			}
+		}
+		execItems(t, &cnxIn,
+			db.StData{"ID": 2, "NAME": "b2", "VERSION": 4},
+			db.StData{"ID": 4, "NAME": "d", "VERSION": 5},
+		)
+		err := merge.Process()
+		if err != nil {
+			t.Fatalf("Error:%s", err.Error())
+		}
+		row, err := cnxOut.QueryOne(db.StQuery{Querie: `SELECT COUNT(*) AS CANT, MAX(NAME) AS NAME FROM ITEMS WHERE ID IN (2,4)`}, true)
+		if err != nil {
+			t.Fatalf("Error:%s", err.Error())
+		}
+		if cant, _ := row.ToInt("CANT"); cant != 2 || row["NAME"] != "d" {
+			t.Errorf("invalid target rows:%v", row)
+		}
+		mark, ok, err := state.Load("ITEMS")
+		if err != nil || !ok || mark.Value != "5" || mark.Tp != db.INTP {
+			t.Errorf("invalid watermark:%+v", mark)
+		}
+		/*si la salida falla la marca no avanza*/
+		execItems(t, &cnxIn, db.StData{"ID": 5, "NAME": "e", "VERSION": 6})
+		_, err = cnxOut.ExecNative(`DROP TABLE ITEMS`, true)
+		if err != nil {
+			t.Fatalf("Error:%s", err.Error())
+		}
+		_, err = merge.SyncExt(merge.ItemsExt[0])
+		if err == nil {
+			t.Fatalf("expected error without the target table")
+		}
+		mark, _, _ = state.Load("ITEMS")
+		if mark.Value != "5" {
+			t.Errorf("Actual ( %s ) does not match expected ( %s )", mark.Value, "5")
+		}
+	}
+}
+
+/*TestSyncWatermarkText : las marcas numericas que llegan como texto se comparan como numero*/
+func TestSyncWatermarkText(t *testing.T) {
+	cnxIn, cnxOut := newSqlite(t), newSqlite(t)
+	newSyncTable(t, &cnxIn)
+	newSyncTable(t, &cnxOut)
+	state := db.NewStateFile(filepath.Join(t.TempDir(), "state.json"))
+	merge := db.StMerge{
+		CnxIn:  cnxIn,
+		CnxOut: cnxOut,
+		State:  state,
+		ItemsExt: []db.StExt{{
+			SQLIn:        db.StQuery{Querie: `SELECT ID, NAME, CAST(VERSION AS TEXT) AS VERSION FROM ITEMS`},
+			TableNameOut: "ITEMS",
+			Index:        []string{"ID"},
+			Watermark:    "VERSION",
+		}},
+	}
+	execItems(t, &cnxIn,
+		db.StData{"ID": 1, "NAME": "a", "VERSION": 9},
+		db.StData{"ID": 2, "NAME": "b", "VERSION": 10},
+		db.StData{"ID": 3, "NAME": "c", "VERSION": 8},
+	)
+	cant, err := merge.SyncExt(merge.ItemsExt[0])
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if cant != 3 {
+		t.Errorf("Actual ( %d ) does not match expected ( %d )", cant, 3)
+	}
+	mark, ok, err := state.Load("ITEMS")
+	if err != nil || !ok || mark.Value != "10" {
+		t.Errorf("invalid watermark:%+v", mark)
+	}
+}
+
+/*TestSyncDiff : compara la entrada con la salida y aplica solo los cambios necesarios*/
+func TestSyncDiff(t *testing.T) {
+	cnxIn, cnxOut := newSqlite(t), newSqlite(t)
+	newSyncTable(t, &cnxIn)
+	newSyncTable(t, &cnxOut)
+	execItems(t, &cnxIn,
+		db.StData{"ID": 1, "NAME": "a", "VERSION": 1},
+		db.StData{"ID": 2, "NAME": "b2", "VERSION": 2},
+		db.StData{"ID": 3, "NAME": "c", "VERSION": 3},
+		db.StData{"ID": 4, "NAME": "d", "VERSION": 4},
+	)
+	execItems(t, &cnxOut,
+		db.StData{"ID": 1, "NAME": "a", "VERSION": 1},
+		db.StData{"ID": 2, "NAME": "b", "VERSION": 2},
+		db.StData{"ID": 3, "NAME": "c", "VERSION": 3},
+		db.StData{"ID": 5, "NAME": "e", "VERSION": 5},
+	)
+	merge := db.StMerge{
+		CnxIn:  cnxIn,
+		CnxOut: cnxOut,
+		DryRun: true,
+		ItemsExt: []db.StExt{{
+			SQLIn:        db.StQuery{Querie: `SELECT ID, NAME, VERSION FROM ITEMS`},
+			TableNameOut: "ITEMS",
+			Index:        []string{"ID"},
+			Diff:         true,
+		}},
+	}
+	err := merge.Process()
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	report := merge.Reports[0]
+	if report.Inserts != 1 || report.Updates != 1 || report.Deletes != 1 || report.Unchanged != 2 {
+		t.Errorf("invalid report:%+v", report)
+	}
+	if id, _ := report.Samples[db.DELETE][0].ToInt("ID"); id != 5 {
+		t.Errorf("Actual ( %d ) does not match expected ( %d )", id, 5)
+	}
+	rows, _ := cnxOut.QueryMap(db.StQuery{Querie: `SELECT * FROM ITEMS WHERE ID = 5`}, 0, true, false)
+	if len(rows) != 1 {
+		t.Fatalf("the dry run modified the target")
+	}
+	report, err = merge.DiffExt(merge.ItemsExt[0], false)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if report.Inserts != 1 || report.Updates != 1 || report.Deletes != 1 || report.Unchanged != 2 {
+		t.Errorf("invalid report of the sync:%+v", report)
+	}
+	report, err = merge.DiffExt(merge.ItemsExt[0], true)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if report.Inserts != 0 || report.Updates != 0 || report.Deletes != 0 || report.Unchanged != 4 {
+		t.Errorf("invalid report after sync:%+v", report)
+	}
+}
+
+/*TestProcessParallel : ejecuta los extractores en paralelo escribiendo la salida por bloques*/
+func TestProcessParallel(t *testing.T) {
+	cnxIn, cnxOut := newSqlite(t), newSqlite(t)
+	newSyncTable(t, &cnxIn)
+	newSyncTable(t, &cnxOut)
+	var rows []db.StData
+	for i := 1; i <= 2500; i++ {
+		rows = append(rows, db.StData{"ID": i, "NAME": "item", "VERSION": i})
+	}
+	execItems(t, &cnxIn, rows...)
+	insertClients(t, &cnxIn, 10)
+	last := make(map[string]db.StProgress)
+	merge := db.StMerge{
+		CnxIn:   cnxIn,
+		CnxOut:  cnxOut,
+		Workers: 2,
+		Chunk:   1000,
+		Batch:   100,
+		ItemsExt: []db.StExt{
+			{SQLIn: db.StQuery{Querie: `SELECT * FROM ITEMS`}, TableNameOut: "ITEMS"},
+			{SQLIn: db.StQuery{Querie: `SELECT * FROM CLIENTS`}, TableNameOut: "CLIENTS"},
+		},
+		Progress: func(item db.StProgress) {
+			last[item.Table] = item
+		},
+	}
+	err := merge.ProcessParallel(context.Background())
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	for table, cant := range map[string]int{"ITEMS": 2500, "CLIENTS": 10} {
+		item := last[table]
+		if !item.Done || item.Read != cant || item.Written != cant {
+			t.Errorf("invalid progress:%+v", item)
+		}
+		row, err := cnxOut.QueryOne(db.StQuery{Querie: fmt.Sprintf(`SELECT COUNT(*) AS CANT FROM %s`, table)}, true)
+		if err != nil {
+			t.Fatalf("Error:%s", err.Error())
+		}
+		if total, _ := row.ToInt("CANT"); total != cant {
+			t.Errorf("Actual ( %d ) does not match expected ( %d )", total, cant)
+		}
+	}
+}
+
+/*peakHook : hook de prueba que cuenta las consultas abiertas al mismo tiempo y las detiene hasta que lleguen n*/
+type peakHook struct {
+	mu      sync.Mutex
+	n       int
+	current int
+	peak    int
+	once    sync.Once
+	release chan struct{}
+}
+
+func (p *peakHook) Before(ctx context.Context, stmt *db.StStmt) error {
+	p.mu.Lock()
+	p.current++
+	if p.current > p.peak {
+		p.peak = p.current
+	}
+	if p.current == p.n {
+		p.once.Do(func() { close(p.release) })
+	}
+	p.mu.Unlock()
+	<-p.release
+	return nil
+}
+
+func (p *peakHook) After(ctx context.Context, stmt *db.StStmt) {
+	p.mu.Lock()
+	p.current--
+	p.mu.Unlock()
+}
+
+/*cancelHook : hook de prueba que cancela el contexto al terminar la primera consulta*/
+type cancelHook struct {
+	once   sync.Once
+	cancel context.CancelFunc
+}
+
+func (p *cancelHook) Before(ctx context.Context, stmt *db.StStmt) error { return nil }
+
+func (p *cancelHook) After(ctx context.Context, stmt *db.StStmt) { p.once.Do(p.cancel) }
+
+/*TestProcessParallelWorkers : limita los Workers configurados a los cpu disponibles
+y regresa el error del contexto si se cancela antes de procesar todos los extractores*/
+func TestProcessParallelWorkers(t *testing.T) {
+	cnxIn, cnxOut := newSqlite(t), newSqlite(t)
+	workers := runtime.NumCPU() + 1
+	expected := utl.MaxCPUtask()
+	hook := &peakHook{n: expected, release: make(chan struct{})}
+	cnxIn.AddHook(hook)
+	merge := db.StMerge{
+		CnxIn:   cnxIn,
+		CnxOut:  cnxOut,
+		Workers: workers,
+	}
+	for i := 0; i < workers; i++ {
+		merge.ItemsExt = append(merge.ItemsExt, db.StExt{SQLIn: db.StQuery{Querie: `SELECT * FROM CLIENTS`}, TableNameOut: "CLIENTS"})
+	}
+	err := merge.ProcessParallel(context.Background())
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if hook.peak != expected {
+		t.Errorf("Actual ( %d ) does not match expected ( %d )", hook.peak, expected)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cnxIn.Hooks = nil
+	cnxIn.AddHook(&cancelHook{cancel: cancel})
+	merge = db.StMerge{
+		CnxIn:   cnxIn,
+		CnxOut:  cnxOut,
+		Workers: 1,
+		ItemsExt: []db.StExt{
+			{SQLIn: db.StQuery{Querie: `SELECT * FROM CLIENTS`}, TableNameOut: "CLIENTS"},
+			{SQLIn: db.StQuery{Querie: `SELECT * FROM CLIENTS`}, TableNameOut: "CLIENTS"},
+		},
+	}
+	err = merge.ProcessParallel(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Actual ( %v ) does not match expected ( %v )", err, context.Canceled)
+	}
+}
+
+/*poolHook : hook de prueba que falla el primer insert con una conexion invalida
+y en el reintento prueba el pool compartido*/
+type poolHook struct {
+	calls   int
+	pool    *sqlx.DB
+	errPing error
+}
+
+func (p *poolHook) Before(ctx context.Context, stmt *db.StStmt) error {
+	p.calls++
+	if p.calls == 1 {
+		return driver.ErrBadConn
+	}
+	if p.calls == 2 {
+		p.errPing = p.pool.PingContext(ctx)
+	}
+	return nil
+}
+
+func (p *poolHook) After(ctx context.Context, stmt *db.StStmt) {}
+
+/*TestProcessParallelRetry : el reintento de un extractor no cierra el pool que usan los demas*/
+func TestProcessParallelRetry(t *testing.T) {
+	cnxIn, cnxOut := newSqlite(t), newSqlite(t)
+	insertClients(t, &cnxIn, 1)
+	cnxOut.Conexion.Retries = 1
+	cnxOut.Conexion.RetryDelay = 1
+	hook := &poolHook{pool: cnxOut.DBGO}
+	cnxOut.AddHook(hook)
+	merge := db.StMerge{
+		CnxIn:    cnxIn,
+		CnxOut:   cnxOut,
+		ItemsExt: []db.StExt{{SQLIn: db.StQuery{Querie: `SELECT * FROM CLIENTS`}, TableNameOut: "CLIENTS"}},
+	}
+	err := merge.ProcessParallel(context.Background())
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if hook.calls != 2 || hook.errPing != nil {
+		t.Errorf("Actual ( %d calls %v ) does not match expected ( 2 calls <nil> )", hook.calls, hook.errPing)
+	}
+}
@@ -0,0 +1,104 @@
+package test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	db "github.com/rafael180496/core-util/database"
+	utl "github.com/rafael180496/core-util/utility"
+)
+
+/*TestTransformRows : aplica los pasos de configuracion y las transformaciones en go*/
+func TestTransformRows(t *testing.T) {
+	var steps []db.StStep
+	err := json.Unmarshal([]byte(`[
+		{"type":"filter","column":"age","op":">=","value":"18"},
+		{"type":"rename","column":"name","to":"full_name"},
+		{"type":"compute","column":"label","value":"{FULL_NAME}-{ID}"},
+		{"type":"cast","column":"age","tp":"string"},
+		{"type":"mask","column":"card","keep":4},
+		{"type":"hash","column":"email","value":"salt"},
+		{"type":"drop","columns":["temp"]}
+	]`), &steps)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	ext := db.StExt{
+		Steps: steps,
+		Transforms: []db.StTransform{func(row db.StData) (db.StData, bool, error) {
+			row["FULL_NAME"] = strings.ToUpper(row["FULL_NAME"].(string))
+			return row, true, nil
+		}},
+	}
+	rows, err := ext.TransformRows([]db.StData{
+		{"id": 1, "name": "pedro", "age": 30, "card": "4111111111111111", "email": "p@test.com", "temp": "x"},
+		{"id": 2, "name": "juan", "age": 10, "card": "4222", "email": "j@test.com", "temp": "y"},
+	})
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if len(rows) != 1 {
+		t.Fatalf("Actual ( %d ) does not match expected ( %d )", len(rows), 1)
+	}
+	expected := db.StData{
+		"ID":        1,
+		"FULL_NAME": "PEDRO",
+		"LABEL":     "pedro-1",
+		"AGE":       "30",
+		"CARD":      "************1111",
+		"EMAIL":     utl.GeneredHashSha256("saltp@test.com"),
+	}
+	if len(rows[0]) != len(expected) {
+		t.Errorf("Actual ( %v ) does not match expected ( %v )", rows[0], expected)
+	}
+	for key, vl := range expected {
+		if rows[0][key] != vl {
+			t.Errorf("column %s: Actual ( %v ) does not match expected ( %v )", key, rows[0][key], vl)
+		}
+	}
+	_, err = (&db.StExt{Steps: []db.StStep{{Type: "filter", Column: "age", Op: "like"}}}).TransformRows(nil)
+	if err == nil {
+		t.Errorf("expected error with an invalid operator")
+	}
+}
+
+/*TestTransformMaskShort : los valores que no son mas largos que Keep se ocultan al menos en el primer caracter*/
+func TestTransformMaskShort(t *testing.T) {
+	ext := db.StExt{Steps: []db.StStep{{Type: db.STEPMASK, Column: "card", Keep: 4}}}
+	rows, err := ext.TransformRows([]db.StData{{"card": "123"}, {"card": "4222"}, {"card": "42221"}})
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	for i, expected := range []string{"*23", "*222", "*2221"} {
+		if rows[i]["CARD"] != expected {
+			t.Errorf("Actual ( %v ) does not match expected ( %s )", rows[i]["CARD"], expected)
+		}
+	}
+}
+
+/*TestProcessTransform : anonimiza los datos al copiar la tabla a otra base de datos*/
+func TestProcessTransform(t *testing.T) {
+	cnxIn, cnxOut := newSqlite(t), newSqlite(t)
+	insertClients(t, &cnxIn, 3)
+	merge := db.StMerge{
+		CnxIn:  cnxIn,
+		CnxOut: cnxOut,
+		ItemsExt: []db.StExt{{
+			SQLIn:        db.StQuery{Querie: `SELECT * FROM CLIENTS`},
+			TableNameOut: "CLIENTS",
+			Steps:        []db.StStep{{Type: db.STEPHASH, Column: "NAME"}},
+		}},
+	}
+	err := merge.Process()
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	row, err := cnxOut.QueryOne(db.StQuery{Querie: `SELECT NAME FROM CLIENTS WHERE ID = 1`}, true)
+	if err != nil {
+		t.Fatalf("Error:%s", err.Error())
+	}
+	if row["NAME"] != utl.GeneredHashSha256("client1") {
+		t.Errorf("the column was not hashed:%v", row["NAME"])
+	}
+}
@@ -34,15 +34,16 @@ var (
 
 	/*EXT : extensiones de archivos */
 	EXT = map[string]string{
-		"JSON": ".json",
-		"INI":  ".ini",
-		"XML":  ".xml",
-		"RUT":  ".rut",
-		"SQL":  ".sql",
-		"DB":   ".db",
-		"CSV":  ".csv",
-		"TXT":  ".txt",
-		"DBX":  ".dbx",
+		"JSON":   ".json",
+		"INI":    ".ini",
+		"XML":    ".xml",
+		"RUT":    ".rut",
+		"SQL":    ".sql",
+		"DB":     ".db",
+		"CSV":    ".csv",
+		"TXT":    ".txt",
+		"DBX":    ".dbx",
+		"NDJSON": ".ndjson",
 	}
 )
 
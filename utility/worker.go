@@ -15,11 +15,13 @@ type (
 		Pathlog   string       `json:"pathlog"`
 		Debug     bool         `json:"debug"`
 	}
-	/*FormatWorker : contiene los formatos de los worker para poder obtenerlos de forma de un json*/
+	/*FormatWorker : contiene los formatos de los worker para poder obtenerlos de forma de un json
+	Job : ruta opcional de un archivo de job etl que se carga con database.LoadJobsWorker*/
 	FormatWorker []struct {
 		Tp  string `json:"type"`
 		Key string `json:"key"`
 		Fr  string `json:"format"`
+		Job string `json:"job"`
 	}
 	/*MasterWorker : contiene varios worker y se administran de forma individual con maps*/
 	MasterWorker struct {